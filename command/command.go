@@ -0,0 +1,52 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+
+	"template/model"
+)
+
+type command struct {
+	Usage string
+	Run   func(args []string) error
+}
+
+// 命令行命令应在此处注册，使用方式为 `go run . <命令> [参数]`
+var commands = map[string]command{
+	"reencrypt": {
+		"reencrypt [batch]    使用当前密钥重新加密所有加密字段",
+		reencrypt,
+	},
+	"create-admin": {
+		"create-admin <username> <password>    创建管理员账号",
+		createAdmin,
+	},
+	"set-level": {
+		"set-level <username> <level>    修改用户权限等级，并注销其已登录的会话",
+		setLevel,
+	},
+}
+
+// Run 执行命令行参数指定的命令
+func Run(args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	model.Init()
+	return cmd.Run(args[1:])
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Println("可用命令:")
+	for _, name := range names {
+		fmt.Printf("  %s\n", commands[name].Usage)
+	}
+}
@@ -0,0 +1,22 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+
+	"template/model"
+)
+
+func reencrypt(args []string) error {
+	batch := 100
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid batch size %q", args[0])
+		}
+		batch = n
+	}
+	total, err := model.Reencrypt(batch)
+	fmt.Printf("reencrypted %d rows\n", total)
+	return err
+}
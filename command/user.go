@@ -0,0 +1,38 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"template/common"
+	"template/service"
+)
+
+func createAdmin(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: create-admin <username> <password>")
+	}
+	user, err := (&service.User{}).Register(context.Background(), args[0], args[1], "", common.LevelAdmin)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created admin %s (id %d)\n", user.Username, user.ID)
+	return nil
+}
+
+func setLevel(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: set-level <username> <level>")
+	}
+	level, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid level %q", args[1])
+	}
+	if err := (&service.User{}).SetLevel(args[0], level); err != nil {
+		return err
+	}
+	fmt.Printf("set %s level to %d\n", args[0], level)
+	return nil
+}
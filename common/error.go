@@ -8,16 +8,22 @@ const (
 	OpErr
 	AuthErr
 	LevelErr
+	NotFoundErr
+	TooManyErr
+	QuotaErr
 )
 
 var ErrorMapper = map[uint64]string{
-	1: "内部错误",
-	2: "公开错误",
-	3: "参数错误",
-	4: "系统错误",
-	5: "操作错误",
-	6: "鉴权错误",
-	7: "权限错误",
+	1:  "内部错误",
+	2:  "公开错误",
+	3:  "参数错误",
+	4:  "系统错误",
+	5:  "操作错误",
+	6:  "鉴权错误",
+	7:  "权限错误",
+	8:  "资源不存在",
+	9:  "请求过于频繁",
+	10: "配额不足",
 }
 
 func ErrNew(err error, errType gin.ErrorType) error {
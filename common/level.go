@@ -0,0 +1,7 @@
+package common
+
+// 用户等级，数值越大权限越高
+const (
+	LevelUser  = 1
+	LevelAdmin = 10
+)
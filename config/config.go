@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 
 	_ "github.com/joho/godotenv/autoload"
 )
@@ -11,6 +13,7 @@ var Config struct {
 	AppMode      string
 	AppSecret    string
 	AppLanguage  string
+	AppName      string
 	MysqlHost    string
 	MysqlPort    string
 	MysqlName    string
@@ -19,6 +22,82 @@ var Config struct {
 	AllowOrigins string
 	AllowHeaders string
 	LogLevel     string
+
+	SnowflakeNode int64
+
+	SessionStore           string
+	SessionIdleTimeout     int
+	SessionAbsoluteTimeout int
+	SessionRememberAge     int
+	SessionCookieName      string
+	SessionCookieDomain    string
+	SessionCookiePath      string
+	SessionSameSite        string
+	RedisURL               string
+
+	EncryptKeys   string
+	EncryptKeyID  string
+	BlindIndexKey string
+
+	OIDCProviders []OIDCProvider
+
+	JWTAlg        string
+	JWTKeys       string
+	JWTKeyID      string
+	JWTIssuer     string
+	JWTAccessTTL  int
+	JWTRefreshTTL int
+
+	MFAIssuer        string
+	MFARequiredLevel int
+
+	RBACCacheTTL int
+
+	RateLimitStore string
+
+	CaptchaEnabled bool
+	CaptchaStore   string
+	CaptchaKind    string
+	CaptchaTTL     int
+
+	CSRFEnabled        bool
+	CSRFTrustedOrigins string
+	CSRFExempt         string
+
+	LoginGuardStore    string
+	LoginMaxFailures   int
+	LoginIPMaxFailures int
+	LoginLockDuration  int
+	LoginCaptchaAfter  int
+
+	MailDriver string
+	MailFrom   string
+	MailDir    string
+	SMTPHost   string
+	SMTPPort   int
+	SMTPUser   string
+	SMTPPass   string
+	PublicURL  string
+
+	SMSProvider      string
+	SMSCodeTTL       int
+	SMSSendInterval  int
+	SMSDailyLimit    int
+	SMSIPHourlyLimit int
+
+	WechatAppID   string
+	WechatSecret  string
+	WechatBaseURL string
+}
+
+// OIDCProvider OpenID Connect 身份提供方配置
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
 }
 
 func envOr(env string, or string) string {
@@ -29,6 +108,14 @@ func envOr(env string, or string) string {
 	return or
 }
 
+func envIntOr(env string, or int64) int64 {
+	rt, err := strconv.ParseInt(os.Getenv(env), 10, 64)
+	if err != nil {
+		return or
+	}
+	return rt
+}
+
 func initConfig() {
 	Config.AppProd = os.Getenv("APP_PROD") != ""
 	if Config.AppProd {
@@ -38,12 +125,86 @@ func initConfig() {
 	}
 	Config.AppSecret = envOr("APP_SECRET", "gin-example:secret")
 	Config.AppLanguage = envOr("APP_LANGUAGE", "en")
+	Config.AppName = envOr("APP_NAME", "tz-gin")
 	Config.MysqlHost = envOr("APP_MYSQL_HOST", "127.0.0.1")
 	Config.MysqlPort = envOr("APP_MYSQL_PORT", "3306")
 	Config.MysqlName = envOr("APP_MYSQL_NAME", "static")
 	Config.MysqlUser = envOr("APP_MYSQL_USER", "root")
 	Config.MysqlPass = envOr("APP_MYSQL_PASS", "123456")
 	Config.AllowOrigins = envOr("APP_ALLOW_ORIGINS", "*")
-	Config.AllowHeaders = envOr("APP_ALLOW_HEADERS", "Origin|Content-Length|Content-Type|Authorization")
+	Config.AllowHeaders = envOr("APP_ALLOW_HEADERS", "Origin|Content-Length|Content-Type|Authorization|X-CSRF-Token|X-Captcha-Id|X-Captcha-Answer")
 	Config.LogLevel = envOr("APP_LOG_LEVEL", "info")
+	Config.SnowflakeNode = envIntOr("APP_SNOWFLAKE_NODE", 0)
+	Config.SessionStore = envOr("APP_SESSION_STORE", "cookie")
+	Config.SessionIdleTimeout = int(envIntOr("APP_SESSION_IDLE_TIMEOUT", 1800))
+	Config.SessionAbsoluteTimeout = int(envIntOr("APP_SESSION_ABSOLUTE_TIMEOUT", 43200))
+	Config.SessionRememberAge = int(envIntOr("APP_SESSION_REMEMBER_AGE", 2592000))
+	Config.SessionCookieName = envOr("APP_SESSION_COOKIE_NAME", "tz-sessions")
+	Config.SessionCookieDomain = envOr("APP_SESSION_COOKIE_DOMAIN", "")
+	Config.SessionCookiePath = envOr("APP_SESSION_COOKIE_PATH", "/")
+	Config.SessionSameSite = envOr("APP_SESSION_SAMESITE", "lax")
+	Config.RedisURL = envOr("APP_REDIS_URL", "redis://127.0.0.1:6379/0")
+	Config.EncryptKeys = envOr("APP_ENCRYPT_KEYS", "")
+	Config.EncryptKeyID = envOr("APP_ENCRYPT_KEY_ID", "")
+	Config.BlindIndexKey = envOr("APP_BLIND_INDEX_KEY", "")
+	Config.OIDCProviders = oidcProviders(envOr("APP_OIDC_PROVIDERS", ""))
+	Config.JWTAlg = envOr("APP_JWT_ALG", "HS256")
+	Config.JWTKeys = envOr("APP_JWT_KEYS", "")
+	Config.JWTKeyID = envOr("APP_JWT_KEY_ID", "")
+	Config.JWTIssuer = envOr("APP_JWT_ISSUER", "tz-gin")
+	Config.JWTAccessTTL = int(envIntOr("APP_JWT_ACCESS_TTL", 900))
+	Config.JWTRefreshTTL = int(envIntOr("APP_JWT_REFRESH_TTL", 2592000))
+	Config.MFAIssuer = envOr("APP_MFA_ISSUER", Config.AppName)
+	Config.MFARequiredLevel = int(envIntOr("APP_MFA_REQUIRED_LEVEL", 0))
+	Config.CSRFEnabled = envOr("APP_CSRF_ENABLED", "true") == "true"
+	Config.CSRFTrustedOrigins = envOr("APP_CSRF_TRUSTED_ORIGINS", "")
+	Config.CSRFExempt = envOr("APP_CSRF_EXEMPT", "")
+	Config.CaptchaEnabled = envOr("APP_CAPTCHA_ENABLED", "true") == "true"
+	Config.CaptchaStore = envOr("APP_CAPTCHA_STORE", "memory")
+	Config.CaptchaKind = envOr("APP_CAPTCHA_KIND", "text")
+	Config.CaptchaTTL = int(envIntOr("APP_CAPTCHA_TTL", 120))
+	Config.RateLimitStore = envOr("APP_RATELIMIT_STORE", "memory")
+	Config.RBACCacheTTL = int(envIntOr("APP_RBAC_CACHE_TTL", 60))
+	Config.LoginGuardStore = envOr("APP_LOGIN_GUARD_STORE", "memory")
+	Config.LoginMaxFailures = int(envIntOr("APP_LOGIN_MAX_FAILURES", 5))
+	Config.LoginIPMaxFailures = int(envIntOr("APP_LOGIN_IP_MAX_FAILURES", 50))
+	Config.LoginLockDuration = int(envIntOr("APP_LOGIN_LOCK_DURATION", 900))
+	Config.LoginCaptchaAfter = int(envIntOr("APP_LOGIN_CAPTCHA_AFTER", 3))
+	Config.MailDriver = envOr("APP_MAIL_DRIVER", "file")
+	Config.MailFrom = envOr("APP_MAIL_FROM", "tz-gin <no-reply@localhost>")
+	Config.MailDir = envOr("APP_MAIL_DIR", "mail")
+	Config.SMTPHost = envOr("APP_SMTP_HOST", "")
+	Config.SMTPPort = int(envIntOr("APP_SMTP_PORT", 587))
+	Config.SMTPUser = envOr("APP_SMTP_USER", "")
+	Config.SMTPPass = envOr("APP_SMTP_PASS", "")
+	Config.PublicURL = strings.TrimRight(envOr("APP_PUBLIC_URL", "http://localhost:8080"), "/")
+	Config.SMSProvider = envOr("APP_SMS_PROVIDER", "log")
+	Config.SMSCodeTTL = int(envIntOr("APP_SMS_CODE_TTL", 300))
+	Config.SMSSendInterval = int(envIntOr("APP_SMS_SEND_INTERVAL", 60))
+	Config.SMSDailyLimit = int(envIntOr("APP_SMS_DAILY_LIMIT", 10))
+	Config.SMSIPHourlyLimit = int(envIntOr("APP_SMS_IP_HOURLY_LIMIT", 20))
+	Config.WechatAppID = envOr("APP_WECHAT_APP_ID", "")
+	Config.WechatSecret = envOr("APP_WECHAT_SECRET", "")
+	Config.WechatBaseURL = envOr("APP_WECHAT_BASE_URL", "https://api.weixin.qq.com")
+}
+
+// oidcProviders 读取 APP_OIDC_PROVIDERS 中各身份提供方的 APP_OIDC_<NAME>_* 配置
+func oidcProviders(names string) []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(names, "|") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "APP_OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProvider{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		})
+	}
+	return providers
 }
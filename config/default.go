@@ -6,37 +6,36 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
 )
 
-func InitSession(r *gin.Engine) {
-	store := cookie.NewStore([]byte(Config.AppSecret))
-	opts := sessions.Options{
-		Path:     "/",
-		MaxAge:   1800, // 30 Minutes
-		Secure:   true,
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-	}
-	if !Config.AppProd {
-		opts = sessions.Options{
-			Path:     "/",
-			MaxAge:   1800, // 30 Minutes
-			Secure:   false,
-			HttpOnly: false,
-			SameSite: http.SameSiteLaxMode,
-		}
+// SessionOptions 按配置生成 session Cookie 选项，maxAge 为 Cookie 有效期(秒)
+func SessionOptions(maxAge int) sessions.Options {
+	return sessions.Options{
+		Path:     Config.SessionCookiePath,
+		Domain:   Config.SessionCookieDomain,
+		MaxAge:   maxAge,
+		Secure:   Config.AppProd,
+		HttpOnly: Config.AppProd,
+		SameSite: sameSite(Config.SessionSameSite),
 	}
+}
 
-	store.Options(opts)
-	r.Use(sessions.Sessions("tz-sessions", store))
+func sameSite(mode string) http.SameSite {
+	switch strings.ToLower(mode) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteLaxMode
 }
 
 func SetCORS(r *gin.Engine) {
 	setConfig := cors.DefaultConfig()
 	setConfig.AllowOrigins = split(Config.AllowOrigins)
 	setConfig.AllowHeaders = split(Config.AllowHeaders)
+	setConfig.ExposeHeaders = []string{"X-CSRF-Token", "RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"}
 	r.Use(cors.New(setConfig))
 }
 
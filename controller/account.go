@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"template/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Account struct {
+}
+
+type emailForm struct {
+	Email string `json:"email" binding:"required,email,max=191"`
+}
+
+type userTokenForm struct {
+	Token string `json:"token" binding:"required,max=64"`
+}
+
+func (a *Account) SetEmail(c *gin.Context) {
+	var form emailForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	user, _ := CurrentUser(c)
+
+	if err := srv.Account.SetEmail(actorContext(c), user.ID, form.Email); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+func (a *Account) SendVerification(c *gin.Context) {
+	user, _ := CurrentUser(c)
+
+	if err := srv.Account.SendVerification(actorContext(c), user.ID); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+func (a *Account) VerifyEmail(c *gin.Context) {
+	var form userTokenForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	if err := srv.Account.VerifyEmail(form.Token); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+func (a *Account) ForgotPassword(c *gin.Context) {
+	var form emailForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	if err := srv.Account.RequestPasswordReset(c.Request.Context(), form.Email); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+func (a *Account) ResetPassword(c *gin.Context) {
+	var form struct {
+		userTokenForm
+		Password string `json:"password" binding:"required,min=8,max=72"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	if err := srv.Account.ResetPassword(c.Request.Context(), form.Token, form.Password); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
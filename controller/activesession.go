@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"template/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ActiveSession struct {
+}
+
+type activeSessionUri struct {
+	SID string `uri:"sid" binding:"required,max=36"`
+}
+
+func (a *ActiveSession) List(c *gin.Context) {
+	user, _ := CurrentUser(c)
+
+	resp, err := srv.ActiveSession.List(actorContext(c), user.ID, currentSessionID(c))
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (a *ActiveSession) Revoke(c *gin.Context) {
+	var uri activeSessionUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	if err := srv.ActiveSession.Revoke(actorContext(c), uri.SID); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+func (a *ActiveSession) RevokeAll(c *gin.Context) {
+	var uri common.IDUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	count, err := srv.ActiveSession.RevokeAll(actorContext(c), uri.ID)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, gin.H{"revoked": count}))
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"template/common"
+	"template/model"
+	"template/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKey struct {
+}
+
+// AuthenticateAPIKey 校验 X-API-Key 请求头，通过后当前用户为密钥所属用户，等级不超过密钥的等级
+// 未携带时不做任何事
+func AuthenticateAPIKey(c *gin.Context) error {
+	raw := c.GetHeader("X-API-Key")
+	if raw == "" {
+		return nil
+	}
+	key, user, err := srv.APIKey.Authenticate(c.Request.Context(), raw)
+	if err != nil {
+		return err
+	}
+
+	c.Set("current-user", &UserSession{ID: int(user.ID), Username: user.Username, Level: min(key.Level, user.Level)})
+	c.Set("api-key", key)
+	return nil
+}
+
+// CurrentAPIKey 当前请求使用的 API Key，未使用时 ok 为 false
+func CurrentAPIKey(c *gin.Context) (*model.APIKey, bool) {
+	key, ok := c.Get("api-key")
+	if !ok {
+		return nil, false
+	}
+	return key.(*model.APIKey), true
+}
+
+func (a *APIKey) List(c *gin.Context) {
+	user, _ := CurrentUser(c)
+
+	resp, err := srv.APIKey.List(actorContext(c), user.ID)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (a *APIKey) Create(c *gin.Context) {
+	var form service.APIKeyForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	user, _ := CurrentUser(c)
+	caller, _ := CurrentAPIKey(c)
+
+	resp, err := srv.APIKey.Create(actorContext(c), user.ID, user.Level, caller, form)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (a *APIKey) Revoke(c *gin.Context) {
+	var uri common.IDUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	if err := srv.APIKey.Revoke(actorContext(c), uri.ID); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
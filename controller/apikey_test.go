@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"template/common"
+	"template/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 使用 API Key 创建密钥时，授权范围不能超出当前密钥
+func TestAPIKeyCreate_ScopeEscalation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"name":"escalate","scopes":["*"]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("current-user", &UserSession{ID: 1, Level: common.LevelUser})
+	c.Set("api-key", &model.APIKey{Scopes: "keys"})
+
+	(&APIKey{}).Create(c)
+	if len(c.Errors) == 0 || c.Errors.Last().Type != common.LevelErr {
+		t.Fatalf("expected LevelErr, got %v", c.Errors)
+	}
+}
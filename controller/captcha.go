@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"template/common"
+	"template/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Captcha struct {
+}
+
+// 客户端提交验证码的请求头，不读取请求体，便于在中间件中校验
+const (
+	CaptchaIDHeader     = "X-Captcha-Id"
+	CaptchaAnswerHeader = "X-Captcha-Answer"
+)
+
+func init() {
+	LoginCaptcha = VerifyCaptcha
+}
+
+// VerifyCaptcha 校验请求头中的验证码，APP_CAPTCHA_ENABLED 为 false 时不校验
+func VerifyCaptcha(c *gin.Context) error {
+	if !config.Config.CaptchaEnabled {
+		return nil
+	}
+	return srv.Captcha.Verify(c.Request.Context(), c.GetHeader(CaptchaIDHeader), c.GetHeader(CaptchaAnswerHeader))
+}
+
+func (x *Captcha) Create(c *gin.Context) {
+	var form struct {
+		Kind string `form:"kind" binding:"omitempty,oneof=text math"`
+	}
+	if err := c.ShouldBindQuery(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.Captcha.Create(c.Request.Context(), form.Kind)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
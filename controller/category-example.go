@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"template/common"
+	"template/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Category struct {
+}
+
+func (s *Category) Tree(c *gin.Context) {
+	resp, err := srv.Category.Tree()
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (s *Category) Create(c *gin.Context) {
+	var form struct {
+		Name     string   `json:"name" binding:"required,max=128"`
+		ParentID model.ID `json:"parentId"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.Category.Create(form.Name, form.ParentID)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (s *Category) Move(c *gin.Context) {
+	var uri common.IDUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	var form struct {
+		ParentID model.ID `json:"parentId"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.Category.Move(model.ID(uri.ID), form.ParentID)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (s *Category) Ancestors(c *gin.Context) {
+	var uri common.IDUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.Category.Ancestors(model.ID(uri.ID))
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
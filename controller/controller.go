@@ -2,6 +2,24 @@ package controller
 
 type Controller struct {
 	Hello
+	History
+	Resource
+	Category
+	ActiveSession
+	User
+	OIDC
+	Token
+	APIKey
+	MFA
+	LoginGuard
+	Account
+	SMS
+	Wechat
+	RBAC
+	Policy
+	CSRF
+	Captcha
+	Quota
 }
 
 func New() *Controller {
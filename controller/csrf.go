@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"template/common"
+	"template/config"
+	"template/pkg/csrf"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CSRF struct {
+}
+
+type csrfTokenResponse struct {
+	Token string `json:"token"`
+}
+
+// CSRFHeader 客户端提交令牌的请求头，表单提交时也可使用 _csrf 字段
+const CSRFHeader = "X-CSRF-Token"
+
+// CSRFToken 下发给客户端的令牌，会话中还没有令牌时生成
+func CSRFToken(c *gin.Context) (string, error) {
+	token, ok, _ := SessionGet[string](c, "csrf-token")
+	if !ok {
+		var err error
+		if token, err = csrf.NewToken(); err != nil {
+			return "", common.ErrNew(err, common.SysErr)
+		}
+		if err := SessionSet(c, "csrf-token", token); err != nil {
+			return "", common.ErrNew(err, common.SysErr)
+		}
+	}
+	masked, err := csrf.Mask(token)
+	if err != nil {
+		return "", common.ErrNew(err, common.SysErr)
+	}
+	return masked, nil
+}
+
+// rotateCSRF 登录后更换令牌，并通过响应头下发
+func rotateCSRF(c *gin.Context) error {
+	SessionDelete(c, "csrf-token")
+	token, err := CSRFToken(c)
+	if err != nil {
+		return err
+	}
+	c.Header(CSRFHeader, token)
+	return nil
+}
+
+func csrfTrustedOrigins() []string {
+	if config.Config.CSRFTrustedOrigins != "" {
+		return strings.Split(config.Config.CSRFTrustedOrigins, "|")
+	}
+	if config.Config.AllowOrigins != "*" {
+		return strings.Split(config.Config.AllowOrigins, "|")
+	}
+	return nil
+}
+
+func csrfExempt(c *gin.Context) bool {
+	if _, ok := c.Get("token-claims"); ok {
+		return true
+	}
+	if _, ok := CurrentAPIKey(c); ok {
+		return true
+	}
+	if config.Config.CSRFExempt == "" {
+		return false
+	}
+	for _, prefix := range strings.Split(config.Config.CSRFExempt, "|") {
+		if prefix != "" && strings.HasPrefix(c.Request.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyCSRF 校验 cookie 会话请求的来源及令牌，force 为 false 时 GET、HEAD、OPTIONS 请求不校验
+// 使用访问令牌或 API Key 认证的请求、APP_CSRF_EXEMPT 中的路径不校验，未登录的请求只校验来源
+func VerifyCSRF(c *gin.Context, force bool) error {
+	if !config.Config.CSRFEnabled {
+		return nil
+	}
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		if !force {
+			return nil
+		}
+	}
+	if csrfExempt(c) {
+		return nil
+	}
+	if err := csrf.CheckOrigin(c.Request, csrfTrustedOrigins()); err != nil {
+		return common.ErrNew(errors.New("请求来源不受信任"), common.AuthErr)
+	}
+	// 未登录的会话没有可被冒用的身份，登录请求只校验来源
+	if _, ok, _ := SessionGet[UserSession](c, "user-session"); !ok {
+		return nil
+	}
+
+	submitted := c.GetHeader(CSRFHeader)
+	if submitted == "" {
+		submitted = c.PostForm("_csrf")
+	}
+	token, ok, _ := SessionGet[string](c, "csrf-token")
+	if !ok || !csrf.Verify(token, submitted) {
+		return common.ErrNew(errors.New("CSRF 令牌无效，请刷新页面后重试"), common.AuthErr)
+	}
+	return nil
+}
+
+// Token 获取 CSRF 令牌，前端应在修改数据的请求中通过 X-CSRF-Token 请求头提交
+func (x *CSRF) Token(c *gin.Context) {
+	token, err := CSRFToken(c)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, ResponseNew(c, csrfTokenResponse{Token: token}))
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"template/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVerifyCSRF(t *testing.T) {
+	old := config.Config.CSRFEnabled
+	config.Config.CSRFEnabled = true
+	defer func() { config.Config.CSRFEnabled = old }()
+
+	r := setupRouter()
+	r.POST("/login", func(c *gin.Context) {
+		if err := SessionSet(c, "user-session", UserSession{ID: 1, Level: 1}); err != nil {
+			t.Fatal(err)
+		}
+		token, err := CSRFToken(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		SessionSave(c)
+		c.String(http.StatusOK, token)
+	})
+	r.Any("/action", func(c *gin.Context) {
+		if err := VerifyCSRF(c, false); err != nil {
+			c.String(http.StatusForbidden, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	do := func(method, origin, token string, cookies []*http.Cookie) int {
+		req := httptest.NewRequest(method, "http://api.example.com/action", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if token != "" {
+			req.Header.Set(CSRFHeader, token)
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := do("POST", "", "", nil); code != http.StatusOK {
+		t.Fatalf("anonymous request rejected: %d", code)
+	}
+	if code := do("POST", "https://evil.com", "", nil); code != http.StatusForbidden {
+		t.Fatalf("cross-site request accepted: %d", code)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "http://api.example.com/login", nil))
+	cookies := w.Result().Cookies()
+	token := w.Body.String()
+
+	cases := []struct {
+		name, method, origin, token string
+		want                        int
+	}{
+		{"missing token", "POST", "", "", http.StatusForbidden},
+		{"invalid token", "POST", "", "bad", http.StatusForbidden},
+		{"valid token", "POST", "http://api.example.com", token, http.StatusOK},
+		{"valid token from other site", "POST", "https://evil.com", token, http.StatusForbidden},
+		{"safe method", "GET", "", "", http.StatusOK},
+	}
+	for _, tc := range cases {
+		if code := do(tc.method, tc.origin, tc.token, cookies); code != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, code, tc.want)
+		}
+	}
+
+}
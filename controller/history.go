@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"template/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+type History struct {
+}
+
+type historyUriForm struct {
+	Table string `uri:"table" binding:"required"`
+	ID    string `uri:"id" binding:"required"`
+}
+
+func (h *History) List(c *gin.Context) {
+	var uri historyUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	var form common.PagerForm
+	if err := c.ShouldBindQuery(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.History.List(uri.Table, uri.ID, form)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (h *History) Diff(c *gin.Context) {
+	var uri historyUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	var form struct {
+		From int `form:"from" binding:"required,min=1"`
+		To   int `form:"to" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindQuery(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.History.Diff(uri.Table, uri.ID, form.From, form.To)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (h *History) Revert(c *gin.Context) {
+	var uri historyUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	var form struct {
+		Version int `json:"version" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.History.Revert(actorContext(c), uri.Table, uri.ID, form.Version)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
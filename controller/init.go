@@ -1,11 +1,12 @@
 package controller
 
 import (
-	"encoding/gob"
+	"context"
 	"template/common"
+	"template/model"
+	"template/pkg/policy"
 	"template/service"
 
-	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,8 +18,7 @@ type Response struct {
 }
 
 func ResponseNew(c *gin.Context, obj any) *Response {
-	session := sessions.Default(c)
-	if session.Save() != nil {
+	if SessionSave(c) != nil {
 		return &Response{
 			Success: false,
 			Message: "fail to save session",
@@ -31,8 +31,23 @@ func ResponseNew(c *gin.Context, obj any) *Response {
 	}
 }
 
+// actorContext 携带当前用户及请求ID，供 model 记录数据变更的操作人，同时携带策略引擎使用的环境属性
+func actorContext(c *gin.Context) context.Context {
+	actor := model.Actor{RequestID: c.GetString("request-id")}
+	if user, _ := CurrentUser(c); user != nil {
+		actor.UserID = model.ID(user.ID)
+		actor.Level = user.Level
+	}
+	ctx := policy.ContextWithEnv(c.Request.Context(), map[string]any{
+		"ip":        c.ClientIP(),
+		"userAgent": c.Request.UserAgent(),
+	})
+	return model.ContextWithActor(ctx, actor)
+}
+
 var srv = service.New()
 
 func init() {
-	gob.Register(UserSession{})
+	// 兼容引入版本信封之前直接保存的 UserSession
+	SessionRegister[UserSession]()
 }
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"template/common"
+	"template/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginGuard struct {
+}
+
+// LoginCaptcha 连续登录失败较多时校验验证码，为 nil 时不要求验证码
+var LoginCaptcha func(c *gin.Context) error
+
+func loginAttempt(c *gin.Context, username, method string) service.LoginAttempt {
+	return service.LoginAttempt{
+		Username:  username,
+		Method:    method,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// checkLogin 登录前检查账号及IP是否被锁定并预先记为一次失败，需要时校验验证码
+func checkLogin(c *gin.Context, attempt *service.LoginAttempt) error {
+	captcha, err := srv.LoginGuard.Check(actorContext(c), attempt)
+	if err != nil {
+		return err
+	}
+	if captcha && LoginCaptcha != nil {
+		if err := LoginCaptcha(c); err != nil {
+			// 验证码错误不计入登录失败
+			if rerr := srv.LoginGuard.Release(actorContext(c), attempt); rerr != nil {
+				return rerr
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// loginFailed 鉴权失败时记录失败次数，其他错误撤销预先的计数，返回原错误
+func loginFailed(c *gin.Context, attempt service.LoginAttempt, err error) error {
+	var ginErr *gin.Error
+	if !errors.As(err, &ginErr) || ginErr.Type != common.AuthErr {
+		if rerr := srv.LoginGuard.Release(actorContext(c), &attempt); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+	if gerr := srv.LoginGuard.Fail(actorContext(c), attempt); gerr != nil {
+		return gerr
+	}
+	return err
+}
+
+func (l *LoginGuard) Unlock(c *gin.Context) {
+	var uri common.IDUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	var form struct {
+		IP string `json:"ip" binding:"omitempty,ip"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil && c.Request.ContentLength > 0 {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	if err := srv.LoginGuard.Unlock(actorContext(c), uri.ID, form.IP); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"template/common"
+	"template/model"
+	"template/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFA struct {
+}
+
+// mfaPending 密码等第一因素已通过、等待两步验证的登录
+type mfaPending struct {
+	UserID    int
+	Username  string
+	Level     int
+	Remember  bool
+	Method    string
+	ExpiresAt time.Time
+}
+
+type mfaChallenge struct {
+	MFARequired bool `json:"mfaRequired"`
+}
+
+const mfaPendingTTL = 5 * time.Minute
+
+type mfaCodeForm struct {
+	Code string `json:"code" binding:"required,max=16"`
+}
+
+// loginOrChallenge 未启用两步验证时直接登录，否则记录待验证状态并返回验证提示
+func loginOrChallenge(c *gin.Context, user *model.User, remember bool, attempt service.LoginAttempt) (any, error) {
+	if !user.TOTPEnabled {
+		if err := Login(c, UserSession{ID: int(user.ID), Username: user.Username, Level: user.Level}, remember); err != nil {
+			return nil, err
+		}
+		if err := srv.LoginGuard.Succeed(actorContext(c), attempt, int(user.ID)); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	// 密码已校验通过，待验证时不计为失败，两步验证时重新计数
+	if err := srv.LoginGuard.Release(actorContext(c), &attempt); err != nil {
+		return nil, err
+	}
+	SessionClear(c)
+	SessionRegenerate(c)
+	pending := mfaPending{
+		UserID:    int(user.ID),
+		Username:  user.Username,
+		Level:     user.Level,
+		Remember:  remember,
+		Method:    attempt.Method,
+		ExpiresAt: time.Now().Add(mfaPendingTTL),
+	}
+	if err := SessionSet(c, "mfa-pending", pending); err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return mfaChallenge{MFARequired: true}, nil
+}
+
+// setCurrentMFA 更新 cookie 会话中的两步验证状态，令牌与 API Key 请求不做修改
+func setCurrentMFA(c *gin.Context, mfa bool) error {
+	if _, ok := c.Get("token-claims"); ok {
+		return nil
+	}
+	if _, ok := CurrentAPIKey(c); ok {
+		return nil
+	}
+	user, err := CurrentUser(c)
+	if err != nil || user == nil {
+		return err
+	}
+	session := *user
+	session.MFA = mfa
+	return SessionSetUser(c, session)
+}
+
+// Verify 使用验证码或恢复码完成登录
+func (m *MFA) Verify(c *gin.Context) {
+	var form mfaCodeForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	pending, ok, _ := SessionGet[mfaPending](c, "mfa-pending")
+	if !ok || time.Now().After(pending.ExpiresAt) {
+		SessionDelete(c, "mfa-pending")
+		SessionSave(c)
+		c.Error(common.ErrNew(errors.New("登录已失效，请重新登录"), common.AuthErr))
+		return
+	}
+
+	attempt := loginAttempt(c, pending.Username, pending.Method)
+	attempt.UserID = pending.UserID
+	if err := checkLogin(c, &attempt); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	// 错误次数由 service.MFA 按用户在服务端计数
+	if err := srv.MFA.Verify(c.Request.Context(), pending.UserID, form.Code); err != nil {
+		err = loginFailed(c, attempt, err)
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	user := UserSession{ID: pending.UserID, Username: pending.Username, Level: pending.Level, MFA: true}
+	if err := Login(c, user, pending.Remember); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	if err := srv.LoginGuard.Succeed(actorContext(c), attempt, user.ID); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, user))
+}
+
+func (m *MFA) Setup(c *gin.Context) {
+	user, _ := CurrentUser(c)
+
+	resp, err := srv.MFA.Setup(actorContext(c), user.ID)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (m *MFA) QRCode(c *gin.Context) {
+	user, _ := CurrentUser(c)
+
+	png, err := srv.MFA.QRCode(actorContext(c), user.ID)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+func (m *MFA) Enable(c *gin.Context) {
+	var form mfaCodeForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	user, _ := CurrentUser(c)
+
+	codes, err := srv.MFA.Enable(actorContext(c), user.ID, form.Code)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	if err := setCurrentMFA(c, true); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, codes))
+}
+
+func (m *MFA) Disable(c *gin.Context) {
+	var form mfaCodeForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	user, _ := CurrentUser(c)
+
+	if err := srv.MFA.Disable(actorContext(c), user.ID, form.Code); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	if err := setCurrentMFA(c, false); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+func (m *MFA) RecoveryCodes(c *gin.Context) {
+	var form mfaCodeForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	user, _ := CurrentUser(c)
+
+	codes, err := srv.MFA.RecoveryCodes(actorContext(c), user.ID, form.Code)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, codes))
+}
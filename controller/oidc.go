@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"template/common"
+	"template/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDC struct {
+}
+
+type oidcUri struct {
+	Provider string `uri:"provider" binding:"required,max=32"`
+}
+
+func (o *OIDC) Login(c *gin.Context) {
+	var uri oidcUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	url, flow, err := srv.OIDC.Begin(c.Request.Context(), uri.Provider)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	if err := SessionSet(c, "oidc-flow", flow); err != nil {
+		c.Error(common.ErrNew(err, common.SysErr))
+		return
+	}
+	if err := SessionSave(c); err != nil {
+		c.Error(common.ErrNew(err, common.SysErr))
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
+func (o *OIDC) Callback(c *gin.Context) {
+	var uri oidcUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	var form struct {
+		Code  string `form:"code"`
+		State string `form:"state" binding:"required"`
+		Error string `form:"error"`
+	}
+	if err := c.ShouldBindQuery(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	// 授权状态只能使用一次
+	flow, ok, _ := SessionGet[service.OIDCFlow](c, "oidc-flow")
+	SessionDelete(c, "oidc-flow")
+	if err := SessionSave(c); err != nil {
+		c.Error(common.ErrNew(err, common.SysErr))
+		return
+	}
+	if !ok || flow.Provider != uri.Provider || subtle.ConstantTimeCompare([]byte(flow.State), []byte(form.State)) != 1 {
+		c.Error(common.ErrNew(errors.New("授权状态无效，请重新登录"), common.AuthErr))
+		return
+	}
+	if form.Error != "" || form.Code == "" {
+		c.Error(common.ErrNew(fmt.Errorf("授权失败: %s", form.Error), common.AuthErr))
+		return
+	}
+
+	user, err := srv.OIDC.Callback(c.Request.Context(), flow, form.Code)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	resp, err := loginOrChallenge(c, user, false, loginAttempt(c, user.Username, "oidc"))
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"template/config"
+	"template/pkg/oidc/oidctest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOIDC_LoginAndStateCheck(t *testing.T) {
+	idp, err := oidctest.NewServer("client", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idp.Close()
+	config.Config.OIDCProviders = []config.OIDCProvider{{
+		Name:         "mock",
+		Issuer:       idp.Issuer(),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://app.local/api/oidc/mock/callback",
+	}}
+
+	r := setupRouter()
+	ctrl := &OIDC{}
+	var lastErr string
+	r.GET("/api/oidc/:provider/login", ctrl.Login)
+	r.GET("/api/oidc/:provider/callback", func(c *gin.Context) {
+		ctrl.Callback(c)
+		if len(c.Errors) > 0 {
+			lastErr = c.Errors.Last().Error()
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/oidc/mock/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login should redirect, got %d", w.Code)
+	}
+	loc, _ := url.Parse(w.Header().Get("Location"))
+	if !strings.HasPrefix(loc.String(), idp.Issuer()+"/authorize") || loc.Query().Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorize url %s", loc)
+	}
+	cookieVal := w.Header().Get("Set-Cookie")
+
+	// state 不一致时拒绝
+	req := httptest.NewRequest("GET", "/api/oidc/mock/callback?code=x&state=forged", nil)
+	req.Header.Set("Cookie", cookieVal)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(lastErr, "授权状态无效") {
+		t.Fatalf("forged state should be rejected, got %q", lastErr)
+	}
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+
+	"template/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Policy struct {
+}
+
+// Authorize 以当前用户执行资源类型 typ 的 action 操作的策略，拒绝时返回 LevelErr
+func Authorize(c *gin.Context, typ, action string, resource any) error {
+	return srv.Policy.Authorize(actorContext(c), typ, action, resource)
+}
+
+// Decisions 最近被拒绝的授权决定，用于排查请求被拒绝的原因
+func (p *Policy) Decisions(c *gin.Context) {
+	var form struct {
+		UserID int `form:"userId" binding:"min=0"`
+		Limit  int `form:"limit" binding:"omitempty,min=1,max=200"`
+	}
+	if err := c.ShouldBindQuery(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	if form.Limit == 0 {
+		form.Limit = 20
+	}
+
+	resp := srv.Policy.Decisions(form.UserID, form.Limit)
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"template/common"
+	"template/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Quota struct {
+}
+
+type quotaUriForm struct {
+	Type string `uri:"type" binding:"oneof=user tenant"`
+	ID   int    `uri:"id" binding:"min=1"`
+}
+
+type quotaResourceUriForm struct {
+	quotaUriForm
+	Resource string `uri:"resource" binding:"required,max=64"`
+}
+
+type QuotaOverrideForm struct {
+	Limit *int64 `json:"limit" binding:"required,min=-1"`
+}
+
+// ConsumeQuota 为当前用户占用 n 个单位的配额，未登录时返回 AuthErr
+func ConsumeQuota(c *gin.Context, resource string, n int64) error {
+	user, err := CurrentUser(c)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return common.ErrNew(errors.New("您未登录"), common.AuthErr)
+	}
+	return srv.Quota.Consume(c.Request.Context(), service.UserQuota(user.ID), resource, n)
+}
+
+// Usage 当前用户的配额用量
+func (q *Quota) Usage(c *gin.Context) {
+	user, _ := CurrentUser(c)
+
+	resp, err := srv.Quota.Usage(c.Request.Context(), service.UserQuota(user.ID))
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+// SubjectUsage 指定用户或租户的配额用量
+func (q *Quota) SubjectUsage(c *gin.Context) {
+	var uri quotaUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.Quota.Usage(c.Request.Context(), service.QuotaSubject{Type: uri.Type, ID: uri.ID})
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+// SetOverride 单独设置配额上限
+func (q *Quota) SetOverride(c *gin.Context) {
+	var uri quotaResourceUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	var form QuotaOverrideForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	user, _ := CurrentUser(c)
+
+	subject := service.QuotaSubject{Type: uri.Type, ID: uri.ID}
+	if err := srv.Quota.SetOverride(c.Request.Context(), subject, uri.Resource, *form.Limit, user.ID); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+// DeleteOverride 恢复默认的配额上限
+func (q *Quota) DeleteOverride(c *gin.Context) {
+	var uri quotaResourceUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	subject := service.QuotaSubject{Type: uri.Type, ID: uri.ID}
+	if err := srv.Quota.DeleteOverride(c.Request.Context(), subject, uri.Resource); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"template/common"
+	"template/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CheckRateLimit 按 limit 对 key 限流并设置 RateLimit-* 响应头，超过限制时设置 Retry-After 并返回 TooManyErr
+func CheckRateLimit(c *gin.Context, key string, limit ratelimit.Limit) error {
+	result := srv.RateLimit.Allow(c.Request.Context(), key, limit)
+
+	c.Header("RateLimit-Policy", strconv.Itoa(limit.Limit)+";w="+seconds(limit.Period))
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", seconds(result.Reset))
+	if !result.Allowed {
+		c.Header("Retry-After", seconds(result.RetryAfter))
+		return common.ErrNew(errors.New("请求过于频繁，请稍后再试"), common.TooManyErr)
+	}
+	return nil
+}
+
+// seconds 向上取整的秒数
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
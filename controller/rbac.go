@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"template/common"
+	"template/config"
+	"template/model"
+	"template/pkg/permission"
+	"template/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RBAC struct {
+}
+
+type userRoleUriForm struct {
+	ID     int `uri:"id" binding:"min=1"`
+	RoleID int `uri:"roleId" binding:"min=1"`
+}
+
+// HasPermission 当前用户是否拥有 required 权限，未登录时返回 AuthErr
+// 使用 API Key 时取角色权限与密钥授权范围的交集，即密钥也须授权了 required
+// 与 CheckRole 一致，等级达到 APP_MFA_REQUIRED_LEVEL 的用户未完成两步验证时返回 AuthErr
+func HasPermission(c *gin.Context, required string) (bool, error) {
+	user, err := CurrentUser(c)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, common.ErrNew(errors.New("您未登录"), common.AuthErr)
+	}
+	if level := config.Config.MFARequiredLevel; level > 0 && user.Level >= level && !user.MFA {
+		return false, common.ErrNew(errors.New("该操作需要两步验证"), common.AuthErr)
+	}
+	if key, ok := CurrentAPIKey(c); ok && !permission.Set(key.ScopeList()).Has(required) {
+		return false, nil
+	}
+	return srv.RBAC.HasPermission(c.Request.Context(), user.ID, user.Level, required)
+}
+
+// Permissions 当前用户拥有的权限
+func (r *RBAC) Permissions(c *gin.Context) {
+	user, _ := CurrentUser(c)
+
+	resp, err := srv.RBAC.Permissions(c.Request.Context(), user.ID, user.Level)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (r *RBAC) ListRoles(c *gin.Context) {
+	resp, err := srv.RBAC.ListRoles()
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (r *RBAC) CreateRole(c *gin.Context) {
+	var form service.RoleForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.RBAC.CreateRole(form)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (r *RBAC) UpdateRole(c *gin.Context) {
+	var uri common.IDUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	var form service.RoleForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.RBAC.UpdateRole(model.ID(uri.ID), form)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (r *RBAC) DeleteRole(c *gin.Context) {
+	var uri common.IDUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	if err := srv.RBAC.DeleteRole(model.ID(uri.ID)); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+func (r *RBAC) UserRoles(c *gin.Context) {
+	var uri common.IDUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.RBAC.UserRoles(uri.ID)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (r *RBAC) AssignRole(c *gin.Context) {
+	var uri userRoleUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	if err := srv.RBAC.AssignRole(uri.ID, model.ID(uri.RoleID)); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+func (r *RBAC) UnassignRole(c *gin.Context) {
+	var uri userRoleUriForm
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	if err := srv.RBAC.UnassignRole(uri.ID, model.ID(uri.RoleID)); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
@@ -2,7 +2,13 @@ package controller
 
 import (
 	"encoding/gob"
-	"log"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"template/common"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
@@ -12,41 +18,152 @@ type UserSession struct {
 	ID       int
 	Username string
 	Level    int
+	MFA      bool
 }
 
-func _SessionSave(ss sessions.Session) {
-	if err := ss.Save(); err != nil {
-		log.Fatalf("session save error: %v", err)
+// ErrSessionType session 中保存的值与读取时指定的类型不一致
+var ErrSessionType = errors.New("session value type mismatch")
+
+var sessionTypes sync.Map
+
+// SessionRegister 向 gob 注册 session 中保存的类型，重复调用只注册一次
+// 服务重启后第一次解码 session 前类型就必须已注册，因此应在 init 中调用
+func SessionRegister[T any]() {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() == reflect.Interface {
+		return
 	}
+	if _, loaded := sessionTypes.LoadOrStore(typ, struct{}{}); loaded {
+		return
+	}
+	var zero T
+	gob.Register(zero)
 }
 
-func SessionGet(c *gin.Context, name string) any {
-	session := sessions.Default(c)
-	return session.Get(name)
+// SessionGet 读取 session 中的值，不存在时 ok 为 false，类型不一致时返回 ErrSessionType
+func SessionGet[T any](c *gin.Context, name string) (value T, ok bool, err error) {
+	SessionRegister[T]()
+	raw := sessions.Default(c).Get(name)
+	if raw == nil {
+		return value, false, nil
+	}
+	if schema, registered := sessionSchemas[name]; registered {
+		return sessionGetVersioned[T](c, name, schema, raw)
+	}
+	value, ok = raw.(T)
+	if !ok {
+		return value, false, fmt.Errorf("%w: %s is %T", ErrSessionType, name, raw)
+	}
+	return value, true, nil
 }
 
-func SessionSet(c *gin.Context, name string, body any) {
-	session := sessions.Default(c)
-	if body == nil {
-		return
+// SessionSet 写入 session，在 SessionSave 或 ResponseNew 时统一保存
+func SessionSet[T any](c *gin.Context, name string, value T) error {
+	SessionRegister[T]()
+	if any(value) == nil {
+		return fmt.Errorf("session %s: nil value", name)
+	}
+	if schema, registered := sessionSchemas[name]; registered {
+		payload, err := encodePayload(schema, value)
+		if err != nil {
+			return err
+		}
+		sessions.Default(c).Set(name, payload)
+		c.Set("session-written", true)
+		return nil
+	}
+	sessions.Default(c).Set(name, value)
+	c.Set("session-written", true)
+	return nil
+}
+
+// sessionGetVersioned 读取带版本的 session 数据，旧版本升级后写回
+func sessionGetVersioned[T any](c *gin.Context, name string, schema SessionSchema, raw any) (value T, ok bool, err error) {
+	switch raw := raw.(type) {
+	case sessionPayload:
+		value, upgraded, err := decodePayload[T](schema, raw)
+		if err != nil {
+			return value, false, err
+		}
+		if upgraded {
+			if err := SessionSet(c, name, value); err != nil {
+				return value, false, err
+			}
+		}
+		return value, true, nil
+	case T:
+		// 引入版本之前直接保存的值
+		if err := SessionSet(c, name, raw); err != nil {
+			return raw, false, err
+		}
+		return raw, true, nil
 	}
-	gob.Register(body)
-	session.Set(name, body)
-	_SessionSave(session)
+	return value, false, fmt.Errorf("%w: %s is %T", ErrSessionType, name, raw)
 }
 
-func SessionUpdate(c *gin.Context, name string, body any) {
-	SessionSet(c, name, body)
+func SessionUpdate[T any](c *gin.Context, name string, value T) error {
+	return SessionSet(c, name, value)
 }
 
 func SessionClear(c *gin.Context) {
-	session := sessions.Default(c)
-	session.Clear()
-	_SessionSave(session)
+	sessions.Default(c).Clear()
+	c.Set("session-written", true)
 }
 
 func SessionDelete(c *gin.Context, name string) {
+	sessions.Default(c).Delete(name)
+	c.Set("session-written", true)
+}
+
+// SessionSave 保存本次请求中修改过的 session，未修改时不做任何事
+// 保存时按登录时间及"记住我"设置 Cookie 有效期，不经过 ResponseNew 返回的接口需手动调用
+func SessionSave(c *gin.Context) error {
 	session := sessions.Default(c)
-	session.Delete(name)
-	_SessionSave(session)
+	if c.GetBool("session-written") {
+		session.Options(sessionOptions(c))
+		c.Set("session-written", false)
+	}
+	return session.Save()
+}
+
+// CurrentUser 返回当前登录用户，会话已被注销时清空会话并返回 nil
+func CurrentUser(c *gin.Context) (*UserSession, error) {
+	if user, ok := c.Get("current-user"); ok {
+		return user.(*UserSession), nil
+	}
+	user, ok, err := SessionGet[UserSession](c, "user-session")
+	if err != nil {
+		// 类型不一致或无法升级的旧数据，视为未登录
+		return nil, sessionDestroy(c)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	meta, _, _ := SessionGet[SessionMeta](c, "session-meta")
+	ttl := meta.ttl(time.Now())
+	sessionID, _, _ := SessionGet[string](c, "session-id")
+	if sessionID == "" {
+		id, err := srv.ActiveSession.Register(user.ID, c.ClientIP(), c.Request.UserAgent(), ttl)
+		if err != nil {
+			return nil, err
+		}
+		if err := SessionSet(c, "session-id", id); err != nil {
+			return nil, common.ErrNew(err, common.SysErr)
+		}
+		if err := SessionSave(c); err != nil {
+			return nil, common.ErrNew(err, common.SysErr)
+		}
+	} else {
+		valid, err := srv.ActiveSession.Touch(sessionID, user.ID, c.ClientIP(), c.Request.UserAgent(), ttl)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			return nil, sessionDestroy(c)
+		}
+	}
+
+	c.Set("current-user", &user)
+	return &user, nil
 }
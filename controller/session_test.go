@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -36,34 +37,46 @@ func TestSession_ComplexStruct(t *testing.T) {
 	}
 
 	r.POST("/set_struct", func(c *gin.Context) {
-		SessionSet(c, "profile", profile)
+		if err := SessionSet(c, "profile", profile); err != nil {
+			c.String(500, err.Error())
+			return
+		}
+		if err := SessionSave(c); err != nil {
+			c.String(500, err.Error())
+			return
+		}
 		c.String(200, "ok")
 	})
 
 	r.GET("/get_struct", func(c *gin.Context) {
-		val := SessionGet(c, "profile")
-		if val == nil {
-			c.String(404, "not found")
+		p, ok, err := SessionGet[Profile](c, "profile")
+		if err != nil {
+			c.String(500, "type error")
 			return
 		}
-		p, ok := val.(Profile)
 		if !ok {
-			c.String(500, "type error")
+			c.String(404, "not found")
 			return
 		}
 		c.JSON(200, p)
 	})
 
 	r.POST("/update_struct", func(c *gin.Context) {
-		val := SessionGet(c, "profile")
-		if val == nil {
+		p, ok, _ := SessionGet[Profile](c, "profile")
+		if !ok {
 			c.String(404, "not found")
 			return
 		}
-		p := val.(Profile)
 		p.Name = "alice"
 		p.Meta["age"] = 31
-		SessionUpdate(c, "profile", p)
+		if err := SessionUpdate(c, "profile", p); err != nil {
+			c.String(500, err.Error())
+			return
+		}
+		if err := SessionSave(c); err != nil {
+			c.String(500, err.Error())
+			return
+		}
 		c.String(200, "updated")
 	})
 
@@ -109,3 +122,67 @@ func TestSession_ComplexStruct(t *testing.T) {
 		t.Fatalf("get updated struct failed: %s", w.Body.String())
 	}
 }
+
+func TestSession_TypeMismatch(t *testing.T) {
+	r := setupRouter()
+
+	r.POST("/set", func(c *gin.Context) {
+		_ = SessionSet(c, "user-session", "not a struct")
+		c.JSON(200, ResponseNew(c, nil))
+	})
+	r.GET("/get", func(c *gin.Context) {
+		_, ok, err := SessionGet[UserSession](c, "user-session")
+		if ok || !errors.Is(err, ErrSessionType) {
+			c.String(500, "expected type mismatch")
+			return
+		}
+		c.String(200, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/set", nil))
+	cookieVal := w.Header().Get("Set-Cookie")
+	if cookieVal == "" {
+		t.Fatal("ResponseNew should save a modified session")
+	}
+
+	req := httptest.NewRequest("GET", "/get", nil)
+	req.Header.Set("Cookie", cookieVal)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("get failed: %s", w.Body.String())
+	}
+}
+
+// 只读取 session 的请求不写 Cookie，一次请求中的多次修改只保存一次
+func TestResponseNew_UnchangedSession(t *testing.T) {
+	r := setupRouter()
+	r.POST("/set", func(c *gin.Context) {
+		_ = SessionSet(c, "name", "alice")
+		_ = SessionSet(c, "age", 30)
+		c.JSON(200, ResponseNew(c, nil))
+	})
+	r.GET("/get", func(c *gin.Context) {
+		name, _, _ := SessionGet[string](c, "name")
+		c.JSON(200, ResponseNew(c, name))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/set", nil))
+	cookies := w.Header().Values("Set-Cookie")
+	if len(cookies) != 1 {
+		t.Fatalf("modified session should be saved exactly once, got %q", cookies)
+	}
+
+	req := httptest.NewRequest("GET", "/get", nil)
+	req.Header.Set("Cookie", cookies[0])
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "alice") {
+		t.Fatalf("get failed: %s", w.Body.String())
+	}
+	if cookies := w.Header().Values("Set-Cookie"); len(cookies) != 0 {
+		t.Fatalf("unchanged session should not be saved, got %q", cookies)
+	}
+}
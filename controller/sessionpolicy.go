@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"time"
+
+	"template/common"
+	"template/config"
+	"template/pkg/sessionstore"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// SessionMeta 登录会话的时间信息，用于空闲超时、绝对超时及续期
+type SessionMeta struct {
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	Remember   bool
+}
+
+// 最后活跃时间的续期间隔，避免每个请求都重写 session
+const sessionRenewInterval = time.Minute
+
+// ttl 返回会话剩余有效期，取空闲超时与绝对超时中较早者
+func (m SessionMeta) ttl(now time.Time) time.Duration {
+	idle := time.Duration(config.Config.SessionIdleTimeout) * time.Second
+	absolute := time.Duration(config.Config.SessionAbsoluteTimeout) * time.Second
+	if m.Remember {
+		idle = time.Duration(config.Config.SessionRememberAge) * time.Second
+		absolute = idle
+	}
+	if m.CreatedAt.IsZero() {
+		return idle
+	}
+	return earlier(m.LastSeenAt.Add(idle), m.CreatedAt.Add(absolute)).Sub(now)
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// sessionOptions 按当前会话的剩余有效期生成 Cookie 选项
+func sessionOptions(c *gin.Context) sessions.Options {
+	meta, ok, _ := SessionGet[SessionMeta](c, "session-meta")
+	if !ok {
+		return config.SessionOptions(config.Config.SessionIdleTimeout)
+	}
+	return config.SessionOptions(int(meta.ttl(time.Now()).Seconds()))
+}
+
+// sessionDestroy 清空 session 并删除 Cookie 及服务端数据
+func sessionDestroy(c *gin.Context) error {
+	session := sessions.Default(c)
+	session.Clear()
+	session.Options(config.SessionOptions(-1))
+	c.Set("session-written", false)
+	if err := session.Save(); err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return nil
+}
+
+// SessionRegenerate 保存时更换会话ID，登录及权限变更时调用以防止会话固定
+// Cookie 存储没有会话ID，无需处理
+func SessionRegenerate(c *gin.Context) {
+	if config.Config.SessionStore == "cookie" {
+		return
+	}
+	sessions.Default(c).Set(sessionstore.RegenerateKey, true)
+	c.Set("session-written", true)
+}
+
+// SessionRefresh 检查会话是否超时，未超时则续期，由 middleware.SessionExpiry 在每个请求调用
+func SessionRefresh(c *gin.Context) error {
+	meta, ok, err := SessionGet[SessionMeta](c, "session-meta")
+	if err != nil {
+		return sessionDestroy(c)
+	}
+	now := time.Now()
+	if !ok {
+		// 引入会话时间信息之前登录的会话，从当前时间开始计算
+		if _, logged, _ := SessionGet[UserSession](c, "user-session"); !logged {
+			return nil
+		}
+		meta = SessionMeta{CreatedAt: now, LastSeenAt: now}
+		return SessionSet(c, "session-meta", meta)
+	}
+	if meta.ttl(now) <= 0 {
+		return sessionDestroy(c)
+	}
+	if now.Sub(meta.LastSeenAt) > sessionRenewInterval {
+		meta.LastSeenAt = now
+		return SessionSet(c, "session-meta", meta)
+	}
+	return nil
+}
+
+// Login 登录并登记会话，remember 为 true 时使用"记住我"的有效期
+func Login(c *gin.Context, user UserSession, remember bool) error {
+	SessionClear(c)
+	SessionRegenerate(c)
+	now := time.Now()
+	meta := SessionMeta{CreatedAt: now, LastSeenAt: now, Remember: remember}
+	if err := SessionSet(c, "session-meta", meta); err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if err := SessionSet(c, "user-session", user); err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	id, err := srv.ActiveSession.Register(user.ID, c.ClientIP(), c.Request.UserAgent(), meta.ttl(now))
+	if err != nil {
+		return err
+	}
+	if err := SessionSet(c, "session-id", id); err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if err := rotateCSRF(c); err != nil {
+		return err
+	}
+	c.Set("current-user", &user)
+	return nil
+}
+
+// SessionSetUser 更新当前用户信息，权限变更时同时更换会话ID
+func SessionSetUser(c *gin.Context, user UserSession) error {
+	if old, ok, _ := SessionGet[UserSession](c, "user-session"); ok && old.Level != user.Level {
+		SessionRegenerate(c)
+	}
+	if err := SessionSet(c, "user-session", user); err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	c.Set("current-user", &user)
+	return nil
+}
+
+// Logout 登出并结束会话
+func Logout(c *gin.Context) error {
+	if id, ok, _ := SessionGet[string](c, "session-id"); ok {
+		if err := srv.ActiveSession.End(id); err != nil {
+			return err
+		}
+	}
+	c.Set("current-user", (*UserSession)(nil))
+	return sessionDestroy(c)
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"template/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSessionMeta_TTL(t *testing.T) {
+	config.Config.SessionIdleTimeout = 1800
+	config.Config.SessionAbsoluteTimeout = 3600
+	config.Config.SessionRememberAge = 86400
+	now := time.Now()
+
+	cases := []struct {
+		name string
+		meta SessionMeta
+		want time.Duration
+	}{
+		{"idle", SessionMeta{CreatedAt: now, LastSeenAt: now.Add(-10 * time.Minute)}, 20 * time.Minute},
+		{"absolute", SessionMeta{CreatedAt: now.Add(-50 * time.Minute), LastSeenAt: now}, 10 * time.Minute},
+		{"expired", SessionMeta{CreatedAt: now.Add(-2 * time.Hour), LastSeenAt: now}, -time.Hour},
+		{"remember", SessionMeta{CreatedAt: now.Add(-2 * time.Hour), LastSeenAt: now.Add(-time.Hour), Remember: true}, 22 * time.Hour},
+	}
+	for _, tc := range cases {
+		if got := tc.meta.ttl(now); got != tc.want {
+			t.Errorf("%s: ttl = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSessionRefresh_Expired(t *testing.T) {
+	config.Config.SessionIdleTimeout = 1800
+	config.Config.SessionAbsoluteTimeout = 3600
+	r := setupRouter()
+	r.Use(func(c *gin.Context) {
+		if err := SessionRefresh(c); err != nil {
+			c.String(500, err.Error())
+			c.Abort()
+		}
+	})
+	r.POST("/login", func(c *gin.Context) {
+		created := time.Now().Add(-2 * time.Hour)
+		_ = SessionSet(c, "session-meta", SessionMeta{CreatedAt: created, LastSeenAt: time.Now()})
+		_ = SessionSet(c, "user-session", UserSession{ID: 1, Username: "bob", Level: 1})
+		// 直接保存，跳过按剩余有效期设置 Cookie，模拟客户端仍携带过期会话
+		c.Set("session-written", false)
+		_ = SessionSave(c)
+		c.String(200, "ok")
+	})
+	r.GET("/me", func(c *gin.Context) {
+		_, ok, _ := SessionGet[UserSession](c, "user-session")
+		c.JSON(200, ok)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/login", nil))
+	cookieVal := w.Header().Get("Set-Cookie")
+
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("Cookie", cookieVal)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != "false" {
+		t.Fatalf("expired session should be cleared, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SessionUpgrade 将上一版本的 session 数据升级为下一版本，data 为 JSON 解码后的对象
+type SessionUpgrade func(data map[string]any) error
+
+// SessionSchema session 中某个键保存的数据结构版本
+// Upgrades[v] 负责从版本 v 升级到 v+1，修改结构体字段后应递增 Version 并补充升级函数
+type SessionSchema struct {
+	Version  int
+	Upgrades map[int]SessionUpgrade
+}
+
+// 带版本的 session 数据，应在此处注册
+var sessionSchemas = map[string]SessionSchema{
+	"user-session": {Version: 2, Upgrades: map[int]SessionUpgrade{
+		// v1 -> v2: 新增 MFA，旧会话视为未完成两步验证
+		1: func(data map[string]any) error {
+			data["MFA"] = false
+			return nil
+		},
+	}},
+	"session-meta": {Version: 1},
+	"oidc-flow":    {Version: 1},
+	"mfa-pending":  {Version: 1},
+}
+
+// ErrSessionVersion session 数据版本高于当前代码，通常发生在回滚部署后
+var ErrSessionVersion = errors.New("session payload version not supported")
+
+// sessionPayload session 中实际保存的信封，数据以 JSON 保存，不依赖 gob 类型信息
+type sessionPayload struct {
+	Version int
+	Data    []byte
+}
+
+func init() {
+	SessionRegister[sessionPayload]()
+}
+
+// encodePayload 按注册的版本将值包装为信封
+func encodePayload(schema SessionSchema, value any) (sessionPayload, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return sessionPayload{}, err
+	}
+	return sessionPayload{Version: schema.Version, Data: data}, nil
+}
+
+// decodePayload 依次执行升级函数后解码为当前结构，upgraded 表示数据需要写回
+func decodePayload[T any](schema SessionSchema, payload sessionPayload) (value T, upgraded bool, err error) {
+	if payload.Version > schema.Version {
+		return value, false, fmt.Errorf("%w: %d > %d", ErrSessionVersion, payload.Version, schema.Version)
+	}
+	data := payload.Data
+	if payload.Version < schema.Version {
+		var obj map[string]any
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return value, false, err
+		}
+		for v := payload.Version; v < schema.Version; v++ {
+			upgrade, ok := schema.Upgrades[v]
+			if !ok {
+				continue
+			}
+			if err := upgrade(obj); err != nil {
+				return value, false, fmt.Errorf("upgrade session from version %d: %w", v, err)
+			}
+		}
+		if data, err = json.Marshal(obj); err != nil {
+			return value, false, err
+		}
+		upgraded = true
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, false, fmt.Errorf("%w: %v", ErrSessionType, err)
+	}
+	return value, upgraded, nil
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDecodePayload_Upgrade(t *testing.T) {
+	type ProfileV3 struct {
+		ID          int
+		DisplayName string
+		Level       int
+	}
+	schema := SessionSchema{
+		Version: 3,
+		Upgrades: map[int]SessionUpgrade{
+			// v1 -> v2: Name 重命名为 DisplayName
+			1: func(data map[string]any) error {
+				data["DisplayName"] = data["Name"]
+				delete(data, "Name")
+				return nil
+			},
+			// v2 -> v3: 新增 Level，默认为 1
+			2: func(data map[string]any) error {
+				data["Level"] = 1
+				return nil
+			},
+		},
+	}
+
+	old, _ := json.Marshal(map[string]any{"ID": 7, "Name": "bob"})
+	v, upgraded, err := decodePayload[ProfileV3](schema, sessionPayload{Version: 1, Data: old})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upgraded || v != (ProfileV3{ID: 7, DisplayName: "bob", Level: 1}) {
+		t.Fatalf("unexpected upgrade result %+v %v", v, upgraded)
+	}
+
+	current, _ := encodePayload(schema, v)
+	if _, upgraded, err := decodePayload[ProfileV3](schema, current); err != nil || upgraded {
+		t.Fatalf("current version should decode as is: %v %v", upgraded, err)
+	}
+
+	_, _, err = decodePayload[ProfileV3](schema, sessionPayload{Version: 4, Data: old})
+	if !errors.Is(err, ErrSessionVersion) {
+		t.Fatalf("expected ErrSessionVersion, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"template/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SMS struct {
+}
+
+type smsCodeForm struct {
+	Phone string `json:"phone" binding:"required,mobile"`
+	Code  string `json:"code" binding:"required,len=6,numeric"`
+}
+
+func (s *SMS) SendCode(c *gin.Context) {
+	var form struct {
+		Phone   string `json:"phone" binding:"required,mobile"`
+		Purpose string `json:"purpose" binding:"required,oneof=login bind"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	if err := srv.SMS.SendCode(actorContext(c), form.Phone, form.Purpose, c.ClientIP()); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+func (s *SMS) Login(c *gin.Context) {
+	var form struct {
+		smsCodeForm
+		Remember bool `json:"remember"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	user, err := srv.SMS.Login(form.Phone, form.Code)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	resp, err := loginOrChallenge(c, user, form.Remember, loginAttempt(c, user.Username, "sms"))
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (s *SMS) Bind(c *gin.Context) {
+	var form smsCodeForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	user, _ := CurrentUser(c)
+
+	if err := srv.SMS.Bind(actorContext(c), user.ID, form.Phone, form.Code); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"template/common"
+	"template/config"
+	"template/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Token struct {
+}
+
+// Authenticate 校验 Authorization: Bearer 访问令牌，通过后当前用户与 cookie 会话登录时相同
+// 未携带令牌时不做任何事
+func Authenticate(c *gin.Context) error {
+	raw, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return nil
+	}
+	claims, err := srv.Token.Verify(strings.TrimSpace(raw))
+	if err != nil {
+		return err
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return common.ErrNew(errors.New("令牌无效"), common.AuthErr)
+	}
+	ttl := time.Duration(config.Config.JWTRefreshTTL) * time.Second
+	valid, err := srv.ActiveSession.Touch(claims.SessionID, userID, c.ClientIP(), c.Request.UserAgent(), ttl)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return common.ErrNew(errors.New("登录已失效"), common.AuthErr)
+	}
+
+	c.Set("current-user", &UserSession{ID: userID, Username: claims.Username, Level: claims.Level, MFA: claims.MFA})
+	c.Set("token-claims", claims)
+	return nil
+}
+
+// currentSessionID 当前请求的会话登记ID，令牌与 cookie 会话均适用
+func currentSessionID(c *gin.Context) string {
+	if claims, ok := c.Get("token-claims"); ok {
+		return claims.(*service.TokenClaims).SessionID
+	}
+	id, _, _ := SessionGet[string](c, "session-id")
+	return id
+}
+
+func (t *Token) Create(c *gin.Context) {
+	var form struct {
+		Username string `json:"username" binding:"required,max=32"`
+		Password string `json:"password" binding:"required,max=72"`
+		Code     string `json:"code" binding:"max=16"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	attempt := loginAttempt(c, form.Username, "token")
+	if err := checkLogin(c, &attempt); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	user, err := srv.User.Login(form.Username, form.Password)
+	if err != nil {
+		err = loginFailed(c, attempt, err)
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	// 令牌客户端没有待验证状态，启用两步验证时需随密码一同提交验证码
+	if user.TOTPEnabled {
+		attempt.UserID = int(user.ID)
+		if form.Code == "" {
+			c.Error(loginFailed(c, attempt, common.ErrNew(errors.New("请输入两步验证码"), common.AuthErr)))
+			return
+		}
+		if err := srv.MFA.Verify(c.Request.Context(), int(user.ID), form.Code); err != nil {
+			err = loginFailed(c, attempt, err)
+			fmt.Printf("controller %v\n", err)
+			c.Error(err)
+			return
+		}
+	}
+	resp, err := srv.Token.Issue(user, user.TOTPEnabled, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	if err := srv.LoginGuard.Succeed(actorContext(c), attempt, int(user.ID)); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (t *Token) Refresh(c *gin.Context) {
+	var form struct {
+		RefreshToken string `json:"refreshToken" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	resp, err := srv.Token.Refresh(form.RefreshToken)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (t *Token) Revoke(c *gin.Context) {
+	claims, ok := c.Get("token-claims")
+	if !ok {
+		c.Error(common.ErrNew(errors.New("未携带访问令牌"), common.AuthErr))
+		return
+	}
+
+	if err := srv.Token.Revoke(claims.(*service.TokenClaims)); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+func (t *Token) JWKS(c *gin.Context) {
+	resp, err := srv.Token.JWKS()
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"template/config"
+	"template/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthenticate_RejectsInvalidTokens(t *testing.T) {
+	config.Config.JWTIssuer = "tz-gin"
+	forged, _ := jwt.Sign(jwt.Header{Alg: jwt.HS256}, map[string]any{
+		"sub": "1", "typ": "access", "lvl": 10, "sid": "s", "jti": "j", "iss": "tz-gin",
+	}, []byte("not the server key"))
+
+	cases := map[string]string{
+		"forged":    "Bearer " + forged,
+		"malformed": "Bearer abc",
+		"none":      "",
+		"basic":     "Basic dXNlcjpwYXNz",
+	}
+	for name, header := range cases {
+		gin.SetMode(gin.TestMode)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		if header != "" {
+			c.Request.Header.Set("Authorization", header)
+		}
+		err := Authenticate(c)
+		_, authed := c.Get("current-user")
+		switch name {
+		case "none", "basic":
+			if err != nil || authed {
+				t.Errorf("%s: should pass through, got %v", name, err)
+			}
+		default:
+			if err == nil || authed {
+				t.Errorf("%s: should be rejected", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"template/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+type User struct {
+}
+
+type userForm struct {
+	Username string `json:"username" binding:"required,min=3,max=32,alphanum"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+	Email    string `json:"email" binding:"omitempty,email,max=191"`
+	Remember bool   `json:"remember"`
+}
+
+func (u *User) Register(c *gin.Context) {
+	var form userForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	user, err := srv.User.Register(actorContext(c), form.Username, form.Password, form.Email, common.LevelUser)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	if err := Login(c, UserSession{ID: int(user.ID), Username: user.Username, Level: user.Level}, form.Remember); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, user))
+}
+
+func (u *User) Login(c *gin.Context) {
+	var form struct {
+		Username string `json:"username" binding:"required,max=32"`
+		Password string `json:"password" binding:"required,max=72"`
+		Remember bool   `json:"remember"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	attempt := loginAttempt(c, form.Username, "password")
+	if err := checkLogin(c, &attempt); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	user, err := srv.User.Login(form.Username, form.Password)
+	if err != nil {
+		err = loginFailed(c, attempt, err)
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	resp, err := loginOrChallenge(c, user, form.Remember, attempt)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (u *User) Logout(c *gin.Context) {
+	if err := Logout(c); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
+
+func (u *User) Me(c *gin.Context) {
+	session, _ := CurrentUser(c)
+
+	resp, err := srv.User.Get(actorContext(c), session.ID)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (u *User) ChangePassword(c *gin.Context) {
+	var form struct {
+		OldPassword string `json:"oldPassword" binding:"required,max=72"`
+		NewPassword string `json:"newPassword" binding:"required,min=8,max=72"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	session, _ := CurrentUser(c)
+
+	if err := srv.User.ChangePassword(actorContext(c), session.ID, form.OldPassword, form.NewPassword); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	// 其他会话已全部注销，当前会话重新登录并更换会话ID
+	meta, _, _ := SessionGet[SessionMeta](c, "session-meta")
+	if err := Login(c, *session, meta.Remember); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, nil))
+}
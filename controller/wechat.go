@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"template/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Wechat struct {
+}
+
+type wechatEncryptedForm struct {
+	EncryptedData string `json:"encryptedData" binding:"required,base64"`
+	IV            string `json:"iv" binding:"required,base64"`
+}
+
+// Login 小程序登录，默认返回访问令牌，session 为 true 时使用 cookie 会话
+func (w *Wechat) Login(c *gin.Context) {
+	var form struct {
+		Code     string `json:"code" binding:"required,max=128"`
+		Session  bool   `json:"session"`
+		TOTPCode string `json:"totpCode" binding:"max=16"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+
+	user, err := srv.Wechat.Login(c.Request.Context(), form.Code)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	attempt := loginAttempt(c, user.Username, "wechat")
+	attempt.UserID = int(user.ID)
+	if form.Session {
+		resp, err := loginOrChallenge(c, user, false, attempt)
+		if err != nil {
+			fmt.Printf("controller %v\n", err)
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, ResponseNew(c, resp))
+		return
+	}
+
+	// 与令牌登录相同，启用两步验证时需一同提交验证码
+	if user.TOTPEnabled {
+		if form.TOTPCode == "" {
+			c.Error(common.ErrNew(errors.New("请输入两步验证码"), common.AuthErr))
+			return
+		}
+		if err := checkLogin(c, &attempt); err != nil {
+			fmt.Printf("controller %v\n", err)
+			c.Error(err)
+			return
+		}
+		if err := srv.MFA.Verify(c.Request.Context(), int(user.ID), form.TOTPCode); err != nil {
+			err = loginFailed(c, attempt, err)
+			fmt.Printf("controller %v\n", err)
+			c.Error(err)
+			return
+		}
+	}
+	resp, err := srv.Token.Issue(user, user.TOTPEnabled, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+	if err := srv.LoginGuard.Succeed(actorContext(c), attempt, int(user.ID)); err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (w *Wechat) BindPhone(c *gin.Context) {
+	var form wechatEncryptedForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	user, _ := CurrentUser(c)
+
+	resp, err := srv.Wechat.BindPhone(actorContext(c), user.ID, form.EncryptedData, form.IV)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
+
+func (w *Wechat) UserInfo(c *gin.Context) {
+	var form wechatEncryptedForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.Error(common.ErrNew(err, common.ParamErr))
+		return
+	}
+	user, _ := CurrentUser(c)
+
+	resp, err := srv.Wechat.UserInfo(user.ID, form.EncryptedData, form.IV)
+	if err != nil {
+		fmt.Printf("controller %v\n", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseNew(c, resp))
+}
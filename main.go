@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"template/command"
 	"template/config"
 	"template/router"
 
@@ -9,6 +11,14 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := command.Run(os.Args[1:]); err != nil {
+			fmt.Printf("fail to run command: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	gin.SetMode(config.Config.AppMode)
 	srv := router.NewServer()
 
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"template/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireCaptcha 要求请求在 X-Captcha-Id 与 X-Captcha-Answer 请求头中提交验证码
+func RequireCaptcha(c *gin.Context) {
+	if err := controller.VerifyCaptcha(c); err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"template/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CSRF 校验 cookie 会话中修改数据的请求，应在 BearerAuth 与 APIKeyAuth 之后注册
+func CSRF(c *gin.Context) {
+	if err := controller.VerifyCSRF(c, false); err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// RequireCSRF 用于会修改数据的 GET 接口，不论请求方法均校验令牌
+func RequireCSRF(c *gin.Context) {
+	if err := controller.VerifyCSRF(c, true); err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
@@ -38,9 +38,20 @@ func Error(c *gin.Context) {
 	}
 }
 
+// 需要返回特定 HTTP 状态码的错误类型，其余均返回 200
+var errorStatus = map[gin.ErrorType]int{
+	common.NotFoundErr: http.StatusNotFound,
+	common.TooManyErr:  http.StatusTooManyRequests,
+	common.QuotaErr:    http.StatusTooManyRequests,
+}
+
 func errorHandle(c *gin.Context, err any) {
 	errMsg := fmt.Sprintf("%v: %v\n", common.ErrorMapper[uint64(c.Errors.Last().Type)], err)
-	c.JSON(http.StatusOK, controller.Response{
+	status, ok := errorStatus[c.Errors.Last().Type]
+	if !ok {
+		status = http.StatusOK
+	}
+	c.JSON(status, controller.Response{
 		Success: false,
 		Message: errMsg,
 		Code:    uint64(c.Errors.Last().Type),
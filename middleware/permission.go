@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"errors"
+
+	"template/common"
+	"template/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission 要求当前用户拥有 perm 权限，权限来自用户的角色，等级达到管理员的用户拥有全部权限
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, err := controller.HasPermission(c, perm)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if !ok {
+			c.Error(common.ErrNew(errors.New("权限不足"), common.LevelErr))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
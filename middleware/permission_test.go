@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"template/common"
+	"template/config"
+	"template/controller"
+	"template/model"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequirePermission_AdminAndAnonymous(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	admin := &controller.UserSession{ID: 1, Level: common.LevelAdmin}
+	cases := []struct {
+		name    string
+		user    *controller.UserSession
+		key     *model.APIKey
+		allowed bool
+		code    gin.ErrorType
+	}{
+		{"admin", admin, nil, true, 0},
+		{"anonymous", nil, nil, false, common.AuthErr},
+		{"admin key without scope", admin, &model.APIKey{Scopes: "keys resources"}, false, common.LevelErr},
+		{"admin key with scope", admin, &model.APIKey{Scopes: "role:*"}, true, 0},
+		{"admin key with wildcard", admin, &model.APIKey{Scopes: "*"}, true, 0},
+	}
+	for _, tc := range cases {
+		reached := false
+		r := gin.New()
+		r.Use(sessions.Sessions("test-session", cookie.NewStore([]byte("secret"))))
+		r.Use(Error)
+		r.GET("/", func(c *gin.Context) {
+			if tc.user != nil {
+				c.Set("current-user", tc.user)
+			}
+			if tc.key != nil {
+				c.Set("api-key", tc.key)
+			}
+		}, RequirePermission("role:manage"), func(c *gin.Context) {
+			reached = true
+			c.Status(http.StatusNoContent)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if reached != tc.allowed || errorCode(w) != tc.code {
+			t.Errorf("%s: reached = %v, error code = %d, want %v %d (%s)", tc.name, reached, errorCode(w), tc.allowed, tc.code, w.Body.String())
+		}
+	}
+}
+
+// 管理员拥有全部权限，但与 CheckRole 一样须先完成两步验证
+func TestRequirePermission_RequireMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	old := config.Config.MFARequiredLevel
+	config.Config.MFARequiredLevel = common.LevelAdmin
+	defer func() { config.Config.MFARequiredLevel = old }()
+
+	cases := []struct {
+		name    string
+		user    controller.UserSession
+		allowed bool
+	}{
+		{"admin without mfa", controller.UserSession{ID: 1, Level: common.LevelAdmin}, false},
+		{"admin with mfa", controller.UserSession{ID: 1, Level: common.LevelAdmin, MFA: true}, true},
+	}
+	for _, tc := range cases {
+		reached := false
+		r := gin.New()
+		r.Use(sessions.Sessions("test-session", cookie.NewStore([]byte("secret"))))
+		r.Use(Error)
+		r.GET("/", func(c *gin.Context) {
+			c.Set("current-user", &tc.user)
+		}, RequirePermission("role:manage"), func(c *gin.Context) {
+			reached = true
+			c.Status(http.StatusNoContent)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		want := map[bool]gin.ErrorType{true: 0, false: common.AuthErr}[tc.allowed]
+		if reached != tc.allowed || errorCode(w) != want {
+			t.Errorf("%s: reached = %v, error code = %d, want %v %d (%s)", tc.name, reached, errorCode(w), tc.allowed, want, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"template/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize 按策略引擎中资源类型 typ 的 action 操作的策略授权
+// load 用于读取被操作的资源，为 nil 时以资源类型整体授权，如创建
+func Authorize(typ, action string, load func(c *gin.Context) (any, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var resource any
+		if load != nil {
+			var err error
+			if resource, err = load(c); err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+		}
+		if err := controller.Authorize(c, typ, action, resource); err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"template/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConsumeQuota 每次请求为当前用户占用 1 个单位的 resource 配额，用完时返回 QuotaErr，未登录的请求不计入
+func ConsumeQuota(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user, _ := controller.CurrentUser(c); user == nil {
+			c.Next()
+			return
+		}
+		if err := controller.ConsumeQuota(c, resource, 1); err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"strconv"
+
+	"template/controller"
+	"template/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc 返回限流的对象，同一路由下返回值相同的请求共享额度
+type KeyFunc func(c *gin.Context) string
+
+// ByIP 按客户端IP限流
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser 按当前登录用户限流，未登录时按IP
+func ByUser(c *gin.Context) string {
+	if user, _ := controller.CurrentUser(c); user != nil {
+		return "user:" + strconv.Itoa(user.ID)
+	}
+	return ByIP(c)
+}
+
+// ByAPIKey 按 API Key 限流，未使用 API Key 时按用户
+func ByAPIKey(c *gin.Context) string {
+	if key, ok := controller.CurrentAPIKey(c); ok {
+		return "key:" + key.ID.String()
+	}
+	return ByUser(c)
+}
+
+// ByRoute 路由的所有请求共享额度
+func ByRoute(*gin.Context) string {
+	return "route"
+}
+
+// RateLimit 在注册路由时声明限流，如
+// r.POST("/login", middleware.RateLimit(ratelimit.Limit{Limit: 10, Period: time.Minute}, middleware.ByIP), ...)
+func RateLimit(limit ratelimit.Limit, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := c.Request.Method + " " + c.FullPath() + " " + key(c)
+		if err := controller.CheckRateLimit(c, k, limit); err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
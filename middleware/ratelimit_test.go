@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"template/config"
+	"template/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Config.RateLimitStore = "memory"
+
+	r := gin.New()
+	r.Use(Error)
+	limit := ratelimit.Limit{Algorithm: ratelimit.SlidingWindow, Limit: 2, Period: time.Minute}
+	r.GET("/limited", RateLimit(limit, ByIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.GET("/other", RateLimit(limit, ByIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i, remaining := range []string{"1", "0"} {
+		w := do("/limited", "10.0.0.1")
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != remaining || w.Header().Get("RateLimit-Limit") != "2" {
+			t.Fatalf("request %d: %d %v", i, w.Code, w.Header())
+		}
+	}
+	w := do("/limited", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("third request should be limited: %d %v", w.Code, w.Header())
+	}
+	if w := do("/limited", "10.0.0.2"); w.Code != http.StatusOK {
+		t.Fatalf("other ip should not be limited: %d", w.Code)
+	}
+	if w := do("/other", "10.0.0.1"); w.Code != http.StatusOK {
+		t.Fatalf("other route should not be limited: %d", w.Code)
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"regexp"
+
+	"template/pkg/idgen"
+
+	"github.com/gin-gonic/gin"
+)
+
+var requestIDPattern = regexp.MustCompile(`^[0-9A-Za-z._-]{1,64}$`)
+
+// RequestID 为每个请求分配请求ID，优先沿用网关传入的 X-Request-ID
+func RequestID(c *gin.Context) {
+	id := c.GetHeader("X-Request-ID")
+	if !requestIDPattern.MatchString(id) {
+		id = idgen.NewULID()
+	}
+	c.Set("request-id", id)
+	c.Header("X-Request-ID", id)
+	c.Next()
+}
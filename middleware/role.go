@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"template/common"
+	"template/config"
 	"template/controller"
 
 	"github.com/gin-gonic/gin"
@@ -11,17 +12,33 @@ import (
 
 func CheckRole(min int) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userSession := controller.SessionGet(c, "user-session")
+		userSession, err := controller.CurrentUser(c)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
 		if userSession == nil {
 			c.Error(common.ErrNew(errors.New("您未登录"), common.AuthErr))
 			c.Abort()
 			return
 		}
-		if userSession.(controller.UserSession).Level < min {
+		if apiKeyDenied(c) {
+			c.Error(common.ErrNew(errors.New("API Key 未授权访问该接口"), common.LevelErr))
+			c.Abort()
+			return
+		}
+		if userSession.Level < min {
 			c.Error(common.ErrNew(errors.New("权限不足"), common.LevelErr))
 			c.Abort()
 			return
 		}
+		// 达到配置等级的接口要求当前登录已完成两步验证
+		if required := config.Config.MFARequiredLevel; required > 0 && min >= required && !userSession.MFA {
+			c.Error(common.ErrNew(errors.New("该操作需要两步验证"), common.AuthErr))
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
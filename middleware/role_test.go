@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"template/common"
+	"template/config"
+	"template/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorCode 返回错误响应中的错误类型，未出错时为 0
+func errorCode(w *httptest.ResponseRecorder) gin.ErrorType {
+	var resp controller.Response
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return gin.ErrorType(resp.Code)
+}
+
+func TestCheckRole_RequireMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	old := config.Config.MFARequiredLevel
+	config.Config.MFARequiredLevel = common.LevelAdmin
+	defer func() { config.Config.MFARequiredLevel = old }()
+
+	cases := []struct {
+		name    string
+		min     int
+		user    controller.UserSession
+		allowed bool
+	}{
+		{"user route", common.LevelUser, controller.UserSession{ID: 1, Level: common.LevelAdmin}, true},
+		{"admin without mfa", common.LevelAdmin, controller.UserSession{ID: 1, Level: common.LevelAdmin}, false},
+		{"admin with mfa", common.LevelAdmin, controller.UserSession{ID: 1, Level: common.LevelAdmin, MFA: true}, true},
+	}
+	for _, tc := range cases {
+		reached := false
+		r := gin.New()
+		r.Use(Error)
+		r.GET("/", func(c *gin.Context) {
+			c.Set("current-user", &tc.user)
+		}, CheckRole(tc.min), func(c *gin.Context) {
+			reached = true
+			c.Status(http.StatusNoContent)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if reached != tc.allowed {
+			t.Errorf("%s: handler reached = %v, want %v (%d %s)", tc.name, reached, tc.allowed, w.Code, w.Body.String())
+		}
+		if want := map[bool]gin.ErrorType{true: 0, false: common.AuthErr}[tc.allowed]; errorCode(w) != want {
+			t.Errorf("%s: error code = %d, want %d", tc.name, errorCode(w), want)
+		}
+	}
+}
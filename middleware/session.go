@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"template/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionExpiry 清除空闲或超过最长有效期的会话，并为活跃会话续期
+func SessionExpiry(c *gin.Context) {
+	if err := controller.SessionRefresh(c); err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
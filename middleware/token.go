@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"template/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BearerAuth 校验请求携带的访问令牌，令牌无效时拒绝请求，未携带时按 cookie 会话处理
+func BearerAuth(c *gin.Context) {
+	if err := controller.Authenticate(c); err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
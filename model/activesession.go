@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ActiveSession 用户的登录会话，用于查看登录设备及强制下线
+type ActiveSession struct {
+	UserID     int        `gorm:"NOT NULL;index;comment:用户主键" json:"userId"`
+	Device     string     `gorm:"type:VARCHAR(64) NOT NULL;comment:设备" json:"device"`
+	IP         string     `gorm:"type:VARCHAR(64) NOT NULL;comment:IP地址" json:"ip"`
+	UserAgent  string     `gorm:"type:VARCHAR(512) NOT NULL;comment:User-Agent" json:"userAgent"`
+	LastSeenAt time.Time  `gorm:"type:DATETIME(3);NOT NULL;comment:最后活跃时间" json:"lastSeenAt"`
+	ExpiresAt  time.Time  `gorm:"type:DATETIME(3);NOT NULL;comment:过期时间" json:"expiresAt"`
+	RevokedAt  *time.Time `gorm:"type:DATETIME(3);NULL;comment:注销时间" json:"revokedAt"`
+
+	StrBaseModel
+}
+
+func (ActiveSession) TableName() string {
+	return "active_session"
+}
+
+func (ActiveSession) OwnerColumn() string {
+	return "user_id"
+}
+
+func (e *ActiveSession) BeforeCreate(_ *gorm.DB) error {
+	return e.AssignID(IDULID)
+}
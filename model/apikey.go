@@ -0,0 +1,51 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey 供脚本及定时任务调用接口的密钥，只保存哈希，Prefix 用于查找及在列表中辨认
+type APIKey struct {
+	UserID     int        `gorm:"NOT NULL;index;comment:用户主键" json:"userId"`
+	Name       string     `gorm:"type:VARCHAR(64) NOT NULL;comment:名称" json:"name"`
+	Prefix     string     `gorm:"type:VARCHAR(16) NOT NULL;uniqueIndex;comment:可见前缀" json:"prefix"`
+	Hash       string     `gorm:"type:CHAR(64) NOT NULL;comment:密钥哈希" json:"-"`
+	Scopes     string     `gorm:"type:VARCHAR(512) NOT NULL;comment:授权范围，空格分隔" json:"scopes"`
+	Level      int        `gorm:"NOT NULL;comment:权限等级，不超过所属用户的等级" json:"level"`
+	RateLimit  int        `gorm:"NOT NULL;default:0;comment:每分钟请求上限，0为不限制" json:"rateLimit"`
+	ExpiresAt  *time.Time `gorm:"type:DATETIME(3);NULL;comment:过期时间" json:"expiresAt"`
+	LastUsedAt *time.Time `gorm:"type:DATETIME(3);NULL;comment:最后使用时间" json:"lastUsedAt"`
+	RevokedAt  *time.Time `gorm:"type:DATETIME(3);NULL;comment:注销时间" json:"revokedAt"`
+
+	BaseModel
+}
+
+func (APIKey) TableName() string {
+	return "api_key"
+}
+
+func (APIKey) OwnerColumn() string {
+	return "user_id"
+}
+
+func (e *APIKey) BeforeCreate(_ *gorm.DB) error {
+	return e.AssignID(IDSnowflake)
+}
+
+// ScopeList 授权范围列表
+func (e APIKey) ScopeList() []string {
+	return strings.Fields(e.Scopes)
+}
+
+// HasScope 判断是否授权了 scope，`*` 表示全部
+func (e APIKey) HasScope(scope string) bool {
+	for _, s := range e.ScopeList() {
+		if s == "*" || s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package model
+
+import "gorm.io/gorm"
+
+type Category struct {
+	Name string `gorm:"type:VARCHAR(128) NOT NULL;comment:名称" json:"name"`
+
+	TreeModel
+	BaseModel
+}
+
+func (Category) TableName() string {
+	return "category"
+}
+
+func (e *Category) BeforeCreate(_ *gorm.DB) error {
+	return e.AssignID(IDSnowflake)
+}
@@ -0,0 +1,26 @@
+package model
+
+import "context"
+
+type actorKey struct{}
+
+// Actor 发起数据操作的用户，通过 DB.WithContext 传入
+type Actor struct {
+	UserID    ID
+	Level     int
+	RequestID string
+}
+
+// ContextWithActor 将操作人写入 context
+func ContextWithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom 读取 context 中的操作人，未设置时第二个返回值为 false
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	if ctx == nil {
+		return Actor{}, false
+	}
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
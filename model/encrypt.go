@@ -0,0 +1,183 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"template/config"
+	"template/pkg/fieldcrypt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// 需要加密的字段使用 `gorm:"serializer:encrypt"` 标记，字段类型为 string 或 []byte
+// 需要重新加密的模型应在此处注册，key 为表名
+var encryptedModels = map[string]func() any{
+	"user":           func() any { return &User{} },
+	"wechat_binding": func() any { return &WechatBinding{} },
+}
+
+func init() {
+	schema.RegisterSerializer("encrypt", EncryptSerializer{})
+}
+
+var keyring = sync.OnceValues(loadKeyring)
+
+func loadKeyring() (*fieldcrypt.Keyring, error) {
+	keys, err := fieldcrypt.ParseKeys(config.Config.EncryptKeys)
+	if err != nil {
+		return nil, err
+	}
+	current := config.Config.EncryptKeyID
+	if len(keys) == 0 {
+		// 未配置时由 APP_SECRET 派生，生产环境必须显式配置
+		if config.Config.AppProd {
+			return nil, errors.New("model: APP_ENCRYPT_KEYS must be configured in production")
+		}
+		derived := sha256.Sum256([]byte("encrypt:" + config.Config.AppSecret))
+		keys["0"] = derived[:]
+		current = "0"
+	}
+	if current == "" && len(keys) == 1 {
+		for id := range keys {
+			current = id
+		}
+	}
+
+	indexKey, err := fieldcrypt.ParseKeys("index:" + config.Config.BlindIndexKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(indexKey["index"]) == 0 {
+		if config.Config.AppProd {
+			return nil, errors.New("model: APP_BLIND_INDEX_KEY must be configured in production")
+		}
+		derived := sha256.Sum256([]byte("blind-index:" + config.Config.AppSecret))
+		indexKey["index"] = derived[:]
+	}
+	return fieldcrypt.NewKeyring(keys, current, indexKey["index"])
+}
+
+// EncryptSerializer 使用 AES-GCM 透明加解密字段，空值不加密
+type EncryptSerializer struct{}
+
+func (EncryptSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+		return nil
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("model: unsupported encrypted value %T", dbValue)
+	}
+
+	var plain []byte
+	if value != "" {
+		k, err := keyring()
+		if err != nil {
+			return err
+		}
+		if plain, err = k.Decrypt(value); err != nil {
+			return fmt.Errorf("model: decrypt %s.%s: %w", field.Schema.Table, field.DBName, err)
+		}
+	}
+
+	fieldValue := field.ReflectValueOf(ctx, dst)
+	switch fieldValue.Kind() {
+	case reflect.String:
+		fieldValue.SetString(string(plain))
+	case reflect.Slice:
+		fieldValue.SetBytes(plain)
+	default:
+		return fmt.Errorf("model: unsupported encrypted field type %s", fieldValue.Type())
+	}
+	return nil
+}
+
+func (EncryptSerializer) Value(_ context.Context, _ *schema.Field, _ reflect.Value, fieldValue any) (any, error) {
+	var plain []byte
+	switch v := fieldValue.(type) {
+	case string:
+		plain = []byte(v)
+	case []byte:
+		plain = v
+	default:
+		return nil, fmt.Errorf("model: unsupported encrypted field type %T", fieldValue)
+	}
+	if len(plain) == 0 {
+		return "", nil
+	}
+	k, err := keyring()
+	if err != nil {
+		return nil, err
+	}
+	return k.Encrypt(plain)
+}
+
+// BlindIndex 计算加密字段的盲索引，写入时存入索引列，查询时以 Where("xxx_index = ?", BlindIndex(...)) 等值匹配
+func BlindIndex(purpose, value string) string {
+	if value == "" {
+		return ""
+	}
+	k, err := keyring()
+	if err != nil {
+		panic(err)
+	}
+	return k.BlindIndex(purpose, value)
+}
+
+// encryptedColumns 返回模型中使用 encrypt 序列化的列
+func encryptedColumns(db *gorm.DB, record any) ([]string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(record); err != nil {
+		return nil, err
+	}
+	var columns []string
+	for _, field := range stmt.Schema.Fields {
+		if field.TagSettings["SERIALIZER"] == "encrypt" {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns, nil
+}
+
+// Reencrypt 使用当前密钥重新加密已注册模型的所有加密字段，历史明文也会被加密，返回处理的记录数
+func Reencrypt(batchSize int) (int64, error) {
+	var total int64
+	for table, newFn := range encryptedModels {
+		record := newFn()
+		columns, err := encryptedColumns(DB, record)
+		if err != nil {
+			return total, err
+		}
+		if len(columns) == 0 {
+			return total, fmt.Errorf("model: %s has no encrypted fields", table)
+		}
+
+		rows := reflect.New(reflect.SliceOf(reflect.TypeOf(record))).Interface()
+		result := DB.Scopes(SkipOwnership).Unscoped().FindInBatches(rows, batchSize, func(tx *gorm.DB, _ int) error {
+			list := reflect.ValueOf(rows).Elem()
+			for i := 0; i < list.Len(); i++ {
+				row := list.Index(i).Interface()
+				// 只写回加密列，不触发钩子也不修改更新时间
+				if err := DB.Scopes(SkipOwnership).Unscoped().Model(row).Select(columns).UpdateColumns(row).Error; err != nil {
+					return err
+				}
+			}
+			total += int64(list.Len())
+			return nil
+		})
+		if result.Error != nil {
+			return total, errors.Join(fmt.Errorf("model: reencrypt %s", table), result.Error)
+		}
+	}
+	return total, nil
+}
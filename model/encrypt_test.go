@@ -0,0 +1,92 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"template/config"
+)
+
+type encryptedExample struct {
+	Phone      string `gorm:"serializer:encrypt"`
+	PhoneIndex string
+	BaseModel
+}
+
+func TestEncryptSerializer(t *testing.T) {
+	db := dryRunDB(t)
+	columns, err := encryptedColumns(db, &encryptedExample{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 1 || columns[0] != "phone" {
+		t.Fatalf("unexpected encrypted columns %v", columns)
+	}
+
+	stmt := db.Create(&encryptedExample{Phone: "13800138000"}).Statement
+	var stored string
+	for _, v := range stmt.Vars {
+		valuer, ok := v.(driver.Valuer)
+		if !ok {
+			continue
+		}
+		if value, err := valuer.Value(); err == nil {
+			if s, ok := value.(string); ok && strings.HasPrefix(s, "enc1:") {
+				stored = s
+			}
+		}
+	}
+	if stored == "" || strings.Contains(stmt.SQL.String(), "13800138000") {
+		t.Fatalf("phone not encrypted: %s %v", stmt.SQL.String(), stmt.Vars)
+	}
+
+	var row encryptedExample
+	field := stmt.Schema.LookUpField("phone")
+	if err := (EncryptSerializer{}).Scan(context.Background(), field, reflect.ValueOf(&row).Elem(), []byte(stored)); err != nil {
+		t.Fatal(err)
+	}
+	if row.Phone != "13800138000" {
+		t.Fatalf("unexpected decrypted phone %q", row.Phone)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	if BlindIndex("phone", "13800138000") != BlindIndex("phone", "13800138000") {
+		t.Fatal("blind index should be deterministic")
+	}
+	if BlindIndex("phone", "") != "" {
+		t.Fatal("blind index of empty value should be empty")
+	}
+}
+
+func TestLoadKeyring_ProdRequiresKeys(t *testing.T) {
+	saved := config.Config
+	t.Cleanup(func() { config.Config = saved })
+
+	config.Config.AppProd = true
+	config.Config.EncryptKeys = ""
+	config.Config.BlindIndexKey = ""
+	if _, err := loadKeyring(); err == nil {
+		t.Fatal("expected error without APP_ENCRYPT_KEYS in production")
+	}
+
+	config.Config.EncryptKeys = "1:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	if _, err := loadKeyring(); err == nil {
+		t.Fatal("expected error without APP_BLIND_INDEX_KEY in production")
+	}
+
+	config.Config.BlindIndexKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	if _, err := loadKeyring(); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Config.AppProd = false
+	config.Config.EncryptKeys = ""
+	config.Config.BlindIndexKey = ""
+	if _, err := loadKeyring(); err != nil {
+		t.Fatal(err)
+	}
+}
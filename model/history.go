@@ -0,0 +1,264 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	HistoryCreate = "create"
+	HistoryUpdate = "update"
+	HistoryDelete = "delete"
+)
+
+// History 被跟踪模型的变更历史，Snapshot 为该版本之后的完整记录，删除时为 null
+type History struct {
+	RecordTable string `gorm:"type:VARCHAR(64) NOT NULL;uniqueIndex:idx_history_version,priority:1;comment:记录所在表" json:"recordTable"`
+	RecordID    string `gorm:"type:VARCHAR(64) NOT NULL;uniqueIndex:idx_history_version,priority:2;comment:记录主键" json:"recordId"`
+	Version     int    `gorm:"NOT NULL;uniqueIndex:idx_history_version,priority:3;comment:版本号" json:"version"`
+	Action      string `gorm:"type:VARCHAR(16) NOT NULL;comment:操作类型" json:"action"`
+	Changes     Fields `gorm:"comment:变更字段" json:"changes"`
+	Snapshot    Fields `gorm:"comment:变更后快照" json:"snapshot"`
+	ActorID     ID     `gorm:"NOT NULL;default:0;comment:操作人" json:"actorId"`
+	RequestID   string `gorm:"type:VARCHAR(64) NOT NULL;default:'';comment:请求ID" json:"requestId"`
+
+	BaseModel
+}
+
+func (History) TableName() string {
+	return "history"
+}
+
+func (e *History) BeforeCreate(_ *gorm.DB) error {
+	return e.AssignID(IDSnowflake)
+}
+
+// FieldChange 单个字段变更前后的值
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// 开启变更历史的模型应在此处注册，key 为表名
+// 被跟踪的记录需通过带主键的实例进行更新和删除，按条件批量修改不会被记录
+var historyModels = map[string]func() any{
+	"resource": func() any { return &Resource{} },
+}
+
+// 不参与变更对比的字段
+var historyIgnored = map[string]bool{
+	"createdAt": true,
+	"updatedAt": true,
+	"deletedAt": true,
+}
+
+// NewTracked 创建已开启变更历史的表对应的模型实例
+func NewTracked(table string) (any, bool) {
+	newFn, ok := historyModels[table]
+	if !ok {
+		return nil, false
+	}
+	return newFn(), true
+}
+
+// DiffSnapshots 对比两个快照，返回发生变化的字段
+func DiffSnapshots(before, after Fields) (map[string]FieldChange, error) {
+	var b, a map[string]any
+	if len(before) != 0 {
+		if err := json.Unmarshal(before, &b); err != nil {
+			return nil, err
+		}
+	}
+	if len(after) != 0 {
+		if err := json.Unmarshal(after, &a); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make(map[string]FieldChange)
+	for k, v := range b {
+		if historyIgnored[k] {
+			continue
+		}
+		if av, ok := a[k]; !ok || !reflect.DeepEqual(v, av) {
+			changes[k] = FieldChange{Before: v, After: a[k]}
+		}
+	}
+	for k, v := range a {
+		if historyIgnored[k] {
+			continue
+		}
+		if _, ok := b[k]; !ok {
+			changes[k] = FieldChange{Before: nil, After: v}
+		}
+	}
+	return changes, nil
+}
+
+func registerHistory(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:after_create").Before("gorm:commit_or_rollback_transaction").
+		Register("history:after_create", historyAfter(HistoryCreate)); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:before_update").Before("gorm:update").
+		Register("history:before_update", historyBefore); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:after_update").Before("gorm:commit_or_rollback_transaction").
+		Register("history:after_update", historyAfter(HistoryUpdate)); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:before_delete").Before("gorm:delete").
+		Register("history:before_delete", historyBefore); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:after_delete").Before("gorm:commit_or_rollback_transaction").
+		Register("history:after_delete", historyAfter(HistoryDelete))
+}
+
+type historyTarget struct {
+	id    string
+	value reflect.Value
+}
+
+func historyEnabled(db *gorm.DB) bool {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Schema.PrioritizedPrimaryField == nil {
+		return false
+	}
+	_, ok := historyModels[db.Statement.Table]
+	return ok
+}
+
+// historyTargets 取出本次操作涉及的、主键非零的记录
+func historyTargets(db *gorm.DB) []historyTarget {
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	var targets []historyTarget
+	collect := func(rv reflect.Value) {
+		rv = reflect.Indirect(rv)
+		if rv.Kind() != reflect.Struct {
+			return
+		}
+		if v, zero := field.ValueOf(db.Statement.Context, rv); !zero {
+			targets = append(targets, historyTarget{id: fmt.Sprint(v), value: rv})
+		}
+	}
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			collect(rv.Index(i))
+		}
+	default:
+		collect(rv)
+	}
+	return targets
+}
+
+// historyLoad 在当前事务中读取记录的最新快照，记录不存在时返回 nil
+// lock 为 true 时加锁读取，并发的修改在事务结束前等待，变更前的快照与本次修改一致
+func historyLoad(db *gorm.DB, id string, lock bool) (Fields, error) {
+	record := historyModels[db.Statement.Table]()
+	pk := db.Statement.Schema.PrioritizedPrimaryField.DBName
+	tx := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Unscoped()
+	if lock {
+		tx = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	err := tx.Where(clause.Eq{Column: clause.Column{Name: pk}, Value: id}).
+		Take(record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(record)
+}
+
+func historyBefore(db *gorm.DB) {
+	if !historyEnabled(db) {
+		return
+	}
+	before := make(map[string]Fields)
+	for _, target := range historyTargets(db) {
+		snapshot, err := historyLoad(db, target.id, true)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		before[target.id] = snapshot
+	}
+	db.InstanceSet("history:before", before)
+}
+
+func historyAfter(action string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if !historyEnabled(db) {
+			return
+		}
+		stored, _ := db.InstanceGet("history:before")
+		before, _ := stored.(map[string]Fields)
+
+		for _, target := range historyTargets(db) {
+			var after Fields
+			var err error
+			switch action {
+			case HistoryCreate:
+				after, err = json.Marshal(target.value.Interface())
+			case HistoryUpdate:
+				after, err = historyLoad(db, target.id, false)
+			}
+			if err == nil {
+				err = recordHistory(db, action, target.id, before[target.id], after)
+			}
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+	}
+}
+
+func recordHistory(db *gorm.DB, action, id string, before, after Fields) error {
+	changes, err := DiffSnapshots(before, after)
+	if err != nil {
+		return err
+	}
+	if action == HistoryUpdate && len(changes) == 0 {
+		return nil
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	tx := db.Session(&gorm.Session{NewDB: true})
+	table := db.Statement.Table
+	// 加锁读取最新版本号，避免快照读取到旧版本导致并发写入时版本号冲突；
+	// 更新和删除时当前事务已持有记录的行锁，同一记录的历史写入在此串行化
+	var version int
+	if err := tx.Model(&History{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("record_table = ? AND record_id = ?", table, id).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error; err != nil {
+		return err
+	}
+
+	actor, _ := ActorFrom(db.Statement.Context)
+	return tx.Create(&History{
+		RecordTable: table,
+		RecordID:    id,
+		Version:     version + 1,
+		Action:      action,
+		Changes:     changesJSON,
+		Snapshot:    after,
+		ActorID:     actor.UserID,
+		RequestID:   actor.RequestID,
+	}).Error
+}
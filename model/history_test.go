@@ -0,0 +1,30 @@
+package model
+
+import "testing"
+
+func TestDiffSnapshots(t *testing.T) {
+	before := Fields(`{"id":"1","name":"a","url":"x","updatedAt":"2024-01-01T00:00:00Z"}`)
+	after := Fields(`{"id":"1","name":"b","url":"x","userId":3,"updatedAt":"2024-01-02T00:00:00Z"}`)
+
+	changes, err := DiffSnapshots(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %v", changes)
+	}
+	if c := changes["name"]; c.Before != "a" || c.After != "b" {
+		t.Fatalf("unexpected name change %v", c)
+	}
+	if c := changes["userId"]; c.Before != nil || c.After != float64(3) {
+		t.Fatalf("unexpected userId change %v", c)
+	}
+
+	deleted, err := DiffSnapshots(after, Fields("null"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := deleted["name"]; !ok || c.Before != "b" || c.After != nil {
+		t.Fatalf("unexpected delete change %v", deleted)
+	}
+}
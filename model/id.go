@@ -0,0 +1,109 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"template/config"
+	"template/pkg/idgen"
+)
+
+// IDStrategy 主键生成策略，由各模型在 BeforeCreate 钩子中选择
+type IDStrategy int
+
+const (
+	IDAutoIncrement IDStrategy = iota // 数据库自增，仅适用于 BaseModel
+	IDSnowflake                       // 雪花ID，仅适用于 BaseModel
+	IDULID                            // ULID，仅适用于 StrBaseModel
+	IDUUIDv7                          // UUIDv7，仅适用于 StrBaseModel
+)
+
+func (s IDStrategy) String() string {
+	switch s {
+	case IDAutoIncrement:
+		return "auto-increment"
+	case IDSnowflake:
+		return "snowflake"
+	case IDULID:
+		return "ulid"
+	case IDUUIDv7:
+		return "uuidv7"
+	}
+	return fmt.Sprintf("IDStrategy(%d)", int(s))
+}
+
+// ID 整型主键，JSON中序列化为字符串以避免 JavaScript 精度丢失
+type ID int64
+
+func (id ID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+func (id ID) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + id.String() + `"`), nil
+}
+
+// UnmarshalJSON 同时兼容字符串与数字两种形式
+func (id *ID) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		var err error
+		if s, err = strconv.Unquote(s); err != nil {
+			return fmt.Errorf("model: invalid id %s", data)
+		}
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("model: invalid id %s", data)
+	}
+	*id = ID(v)
+	return nil
+}
+
+var snowflake = sync.OnceValues(func() (*idgen.Snowflake, error) {
+	return idgen.NewSnowflake(config.Config.SnowflakeNode)
+})
+
+// AssignID 按策略为尚未设置主键的记录生成主键
+func (m *BaseModel) AssignID(strategy IDStrategy) error {
+	if m.ID != 0 {
+		return nil
+	}
+	switch strategy {
+	case IDAutoIncrement:
+		return nil
+	case IDSnowflake:
+		sf, err := snowflake()
+		if err != nil {
+			return err
+		}
+		id, err := sf.Next()
+		if err != nil {
+			return err
+		}
+		m.ID = ID(id)
+		return nil
+	}
+	return fmt.Errorf("model: id strategy %v is not supported by BaseModel", strategy)
+}
+
+// AssignID 按策略为尚未设置主键的记录生成主键
+func (m *StrBaseModel) AssignID(strategy IDStrategy) error {
+	if m.ID != "" {
+		return nil
+	}
+	switch strategy {
+	case IDULID:
+		m.ID = idgen.NewULID()
+		return nil
+	case IDUUIDv7:
+		m.ID = idgen.NewUUIDv7()
+		return nil
+	}
+	return fmt.Errorf("model: id strategy %v is not supported by StrBaseModel", strategy)
+}
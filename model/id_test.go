@@ -0,0 +1,91 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestID_JSON(t *testing.T) {
+	var m struct {
+		ID ID `json:"id"`
+	}
+	m.ID = 1<<62 + 1
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"id":"4611686018427387905"}` {
+		t.Fatalf("unexpected json %s", data)
+	}
+
+	for _, in := range []string{`{"id":"4611686018427387905"}`, `{"id":4611686018427387905}`} {
+		m.ID = 0
+		if err := json.Unmarshal([]byte(in), &m); err != nil {
+			t.Fatal(err)
+		}
+		if m.ID != 1<<62+1 {
+			t.Fatalf("unexpected id %d from %s", m.ID, in)
+		}
+	}
+	for _, in := range []string{`{"id":"abc"}`, `{"id":"\"123"}`, `{"id":"123\""}`, `{"id":"\"123\""}`} {
+		if err := json.Unmarshal([]byte(in), &m); err == nil {
+			t.Fatalf("expected error for invalid id %s", in)
+		}
+	}
+	for _, in := range []string{`"123`, `123"`, `""123""`} {
+		if err := m.ID.UnmarshalJSON([]byte(in)); err == nil {
+			t.Fatalf("expected error for unbalanced quotes %s", in)
+		}
+	}
+}
+
+func TestAssignID(t *testing.T) {
+	var m BaseModel
+	if err := m.AssignID(IDSnowflake); err != nil {
+		t.Fatal(err)
+	}
+	if m.ID == 0 {
+		t.Fatal("expected snowflake id")
+	}
+	id := m.ID
+	if err := m.AssignID(IDSnowflake); err != nil || m.ID != id {
+		t.Fatalf("existing id changed: %d -> %d, %v", id, m.ID, err)
+	}
+
+	var auto BaseModel
+	if err := auto.AssignID(IDAutoIncrement); err != nil || auto.ID != 0 {
+		t.Fatalf("auto-increment should leave id to the database: %d, %v", auto.ID, err)
+	}
+	if err := auto.AssignID(IDULID); err == nil {
+		t.Fatal("expected error for ulid on BaseModel")
+	}
+
+	for strategy, size := range map[IDStrategy]int{IDULID: 26, IDUUIDv7: 36} {
+		var s StrBaseModel
+		if err := s.AssignID(strategy); err != nil {
+			t.Fatal(err)
+		}
+		if len(s.ID) != size {
+			t.Fatalf("%v: unexpected id %q", strategy, s.ID)
+		}
+	}
+	var s StrBaseModel
+	if err := s.AssignID(IDSnowflake); err == nil {
+		t.Fatal("expected error for snowflake on StrBaseModel")
+	}
+}
+
+func TestIDStrategy_String(t *testing.T) {
+	cases := map[IDStrategy]string{
+		IDAutoIncrement: "auto-increment",
+		IDSnowflake:     "snowflake",
+		IDULID:          "ulid",
+		IDUUIDv7:        "uuidv7",
+		IDStrategy(9):   "IDStrategy(9)",
+	}
+	for s, want := range cases {
+		if got := s.String(); got != want {
+			t.Fatalf("%d: got %q, want %q", int(s), got, want)
+		}
+	}
+}
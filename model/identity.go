@@ -0,0 +1,15 @@
+package model
+
+// UserIdentity 外部身份提供方的账号与本地用户的绑定
+type UserIdentity struct {
+	UserID   int    `gorm:"NOT NULL;index;comment:用户主键" json:"userId"`
+	Provider string `gorm:"type:VARCHAR(32) NOT NULL;uniqueIndex:idx_provider_subject;comment:身份提供方" json:"provider"`
+	Subject  string `gorm:"type:VARCHAR(255) NOT NULL;uniqueIndex:idx_provider_subject;comment:外部账号标识" json:"subject"`
+	Email    string `gorm:"type:VARCHAR(255) NOT NULL;comment:邮箱" json:"email"`
+
+	BaseModel
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identity"
+}
@@ -15,7 +15,8 @@ import (
 
 var DB *gorm.DB
 
-func init() {
+// Init 连接数据库，应在服务启动时调用
+func Init() {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&collation=utf8mb4_unicode_ci&&parseTime=True&loc=Local",
 		config.Config.MysqlUser,
 		config.Config.MysqlPass,
@@ -46,7 +47,10 @@ func init() {
 			},
 		)
 	}
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: dbLogger})
+	if _, err := keyring(); err != nil {
+		panic(err)
+	}
+	db, err := Open(mysql.Open(dsn), &gorm.Config{Logger: dbLogger})
 	if err != nil {
 		panic(err)
 	}
@@ -59,11 +63,46 @@ func init() {
 
 }
 
+// Open 打开数据库并注册变更历史及数据归属的回调
+func Open(dialector gorm.Dialector, opts ...gorm.Option) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerHistory(db); err != nil {
+		return nil, err
+	}
+	if err := registerOwnership(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
 func initModel() {
 
+	DB.AutoMigrate(&User{})
+	DB.AutoMigrate(&UserIdentity{})
+	DB.AutoMigrate(&WechatBinding{})
+	DB.AutoMigrate(&RecoveryCode{})
+	DB.AutoMigrate(&UserToken{})
+	DB.AutoMigrate(&SMSCode{})
+	DB.AutoMigrate(&SMSSendCounter{})
+	DB.AutoMigrate(&History{})
+	DB.AutoMigrate(&ActiveSession{})
+	DB.AutoMigrate(&RefreshToken{})
+	DB.AutoMigrate(&RevokedToken{})
+	DB.AutoMigrate(&APIKey{})
+	DB.AutoMigrate(&LoginAudit{})
+	DB.AutoMigrate(&Role{})
+	DB.AutoMigrate(&RolePermission{})
+	DB.AutoMigrate(&UserRole{})
+	DB.AutoMigrate(&QuotaUsage{})
+	DB.AutoMigrate(&QuotaOverride{})
+
 	// example
 	// begin
 	DB.AutoMigrate(&Resource{})
+	DB.AutoMigrate(&Category{})
 	//end
 
 }
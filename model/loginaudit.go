@@ -0,0 +1,33 @@
+package model
+
+import "gorm.io/gorm"
+
+// 登录审计事件
+const (
+	LoginSucceeded = "success"
+	LoginFailed    = "failure"
+	LoginLocked    = "locked"
+	LoginUnlocked  = "unlocked"
+)
+
+// LoginAudit 登录审计记录，用户不存在时 UserID 为 0
+type LoginAudit struct {
+	UserID    int    `gorm:"NOT NULL;index;comment:用户主键" json:"userId"`
+	Username  string `gorm:"type:VARCHAR(64) NOT NULL;index;comment:提交的用户名" json:"username"`
+	Event     string `gorm:"type:VARCHAR(16) NOT NULL;comment:事件" json:"event"`
+	Method    string `gorm:"type:VARCHAR(16) NOT NULL;comment:登录方式" json:"method"`
+	IP        string `gorm:"type:VARCHAR(64) NOT NULL;index;comment:IP地址" json:"ip"`
+	UserAgent string `gorm:"type:VARCHAR(512) NOT NULL;comment:User-Agent" json:"userAgent"`
+	RequestID string `gorm:"type:VARCHAR(64) NOT NULL;comment:请求ID" json:"requestId"`
+	ActorID   int    `gorm:"NOT NULL;default:0;comment:操作人，解锁时为管理员" json:"actorId"`
+
+	BaseModel
+}
+
+func (LoginAudit) TableName() string {
+	return "login_audit"
+}
+
+func (e *LoginAudit) BeforeCreate(_ *gorm.DB) error {
+	return e.AssignID(IDSnowflake)
+}
@@ -5,14 +5,25 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// BaseModel 整型主键，可选数据库自增或雪花ID
 type BaseModel struct {
-	ID        int64          `gorm:"primaryKey;UNSIGNED;NOT NULL;comment:主键" json:"id"`
+	ID        ID             `gorm:"primaryKey;UNSIGNED;NOT NULL;comment:主键" json:"id"`
+	CreatedAt time.Time      `gorm:"type:DATETIME(3);NOT NULL;comment:创建时间" json:"createdAt"`
+	UpdatedAt time.Time      `gorm:"type:DATETIME(3);NOT NULL;comment:更新时间" json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"type:DATETIME(3);NULL;index;comment:删除时间" json:"deletedAt"`
+}
+
+// StrBaseModel 字符串主键，可选ULID或UUIDv7
+type StrBaseModel struct {
+	ID        string         `gorm:"primaryKey;type:VARCHAR(36);NOT NULL;comment:主键" json:"id"`
 	CreatedAt time.Time      `gorm:"type:DATETIME(3);NOT NULL;comment:创建时间" json:"createdAt"`
 	UpdatedAt time.Time      `gorm:"type:DATETIME(3);NOT NULL;comment:更新时间" json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `gorm:"type:DATETIME(3);NULL;index;comment:删除时间" json:"deletedAt"`
@@ -50,3 +61,21 @@ func (n *Fields) UnmarshalJSON(data []byte) error {
 	*n = append((*n)[0:0], data...)
 	return nil
 }
+
+// DuplicateKey 判断是否为唯一索引冲突，返回冲突的索引名
+func DuplicateKey(err error) (string, bool) {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1062 {
+		return "", false
+	}
+	// 错误信息格式为 Duplicate entry '...' for key 'table.index'，MySQL 8 以前不含表名
+	_, key, found := strings.Cut(mysqlErr.Message, "for key '")
+	if !found {
+		return "", true
+	}
+	key = strings.TrimSuffix(key, "'")
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		key = key[i+1:]
+	}
+	return key, true
+}
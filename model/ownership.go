@@ -0,0 +1,68 @@
+package model
+
+import (
+	"reflect"
+
+	"template/common"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Owned 按用户隔离的模型，返回记录归属用户的列名
+// 通过 DB.WithContext 传入 Actor 后，查询、更新、删除会自动限定为该用户的记录
+type Owned interface {
+	OwnerColumn() string
+}
+
+// OwnerOverrider 可选实现，自定义哪些操作人不受归属限制，默认等级不低于 common.LevelAdmin 时不受限制
+type OwnerOverrider interface {
+	OwnerOverride(actor Actor) bool
+}
+
+// SkipOwnership 跳过归属限制，用于系统任务等确需访问全部数据的场景，用法为 DB.Scopes(SkipOwnership)
+func SkipOwnership(db *gorm.DB) *gorm.DB {
+	return db.Set("ownership:skip", true)
+}
+
+func registerOwnership(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Query().Before("gorm:query").Register("ownership:query", ownershipScope); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("ownership:row", ownershipScope); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("ownership:update", ownershipScope); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("ownership:delete", ownershipScope)
+}
+
+func ownershipScope(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	if _, skip := db.Get("ownership:skip"); skip {
+		return
+	}
+	actor, ok := ActorFrom(db.Statement.Context)
+	if !ok {
+		return
+	}
+	owned, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(Owned)
+	if !ok {
+		return
+	}
+	if overrider, ok := owned.(OwnerOverrider); ok {
+		if overrider.OwnerOverride(actor) {
+			return
+		}
+	} else if actor.Level >= common.LevelAdmin {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: owned.OwnerColumn()}, Value: actor.UserID},
+	}})
+}
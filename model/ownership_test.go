@@ -0,0 +1,56 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"template/common"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func dryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registerOwnership(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestOwnership_Scope(t *testing.T) {
+	db := dryRunDB(t)
+	user := ContextWithActor(context.Background(), Actor{UserID: 7, Level: common.LevelUser})
+	admin := ContextWithActor(context.Background(), Actor{UserID: 1, Level: common.LevelAdmin})
+
+	cases := []struct {
+		name   string
+		run    func() *gorm.DB
+		scoped bool
+	}{
+		{"user query", func() *gorm.DB { return db.WithContext(user).Find(&[]Resource{}) }, true},
+		{"user take", func() *gorm.DB { return db.WithContext(user).Take(&Resource{}, 3) }, true},
+		{"user update", func() *gorm.DB {
+			return db.WithContext(user).Model(&Resource{BaseModel: BaseModel{ID: 3}}).Update("name", "x")
+		}, true},
+		{"user delete", func() *gorm.DB { return db.WithContext(user).Delete(&Resource{BaseModel: BaseModel{ID: 3}}) }, true},
+		{"admin query", func() *gorm.DB { return db.WithContext(admin).Find(&[]Resource{}) }, false},
+		{"no actor", func() *gorm.DB { return db.Find(&[]Resource{}) }, false},
+		{"skip", func() *gorm.DB { return db.WithContext(user).Scopes(SkipOwnership).Find(&[]Resource{}) }, false},
+		{"not owned", func() *gorm.DB { return db.WithContext(user).Find(&[]History{}) }, false},
+	}
+	for _, tc := range cases {
+		sql := tc.run().Statement.SQL.String()
+		if got := strings.Contains(sql, "`resource`.`user_id` = ?"); got != tc.scoped {
+			t.Errorf("%s: scoped=%v, sql=%s", tc.name, got, sql)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package model
+
+// 配额归属的对象类型
+const (
+	QuotaSubjectUser   = "user"
+	QuotaSubjectTenant = "tenant"
+)
+
+// QuotaUsage 某个对象在一个统计周期内的用量，Period 如 d:2026-01-02、m:2026-01，不按周期重置的配额为 total
+type QuotaUsage struct {
+	SubjectType string `gorm:"type:VARCHAR(16) NOT NULL;uniqueIndex:idx_quota_usage;comment:对象类型" json:"subjectType"`
+	SubjectID   int    `gorm:"NOT NULL;uniqueIndex:idx_quota_usage;comment:对象主键" json:"subjectId"`
+	Resource    string `gorm:"type:VARCHAR(32) NOT NULL;uniqueIndex:idx_quota_usage;comment:配额名称" json:"resource"`
+	Period      string `gorm:"type:VARCHAR(16) NOT NULL;uniqueIndex:idx_quota_usage;comment:统计周期" json:"period"`
+	Used        int64  `gorm:"NOT NULL;default:0;comment:已用量" json:"used"`
+
+	BaseModel
+}
+
+func (QuotaUsage) TableName() string {
+	return "quota_usage"
+}
+
+// QuotaOverride 管理员为某个对象单独设置的配额上限，Limit 为 -1 时不限制
+type QuotaOverride struct {
+	SubjectType string `gorm:"type:VARCHAR(16) NOT NULL;uniqueIndex:idx_quota_override;comment:对象类型" json:"subjectType"`
+	SubjectID   int    `gorm:"NOT NULL;uniqueIndex:idx_quota_override;comment:对象主键" json:"subjectId"`
+	Resource    string `gorm:"type:VARCHAR(32) NOT NULL;uniqueIndex:idx_quota_override;comment:配额名称" json:"resource"`
+	Limit       int64  `gorm:"column:quota_limit;NOT NULL;comment:配额上限" json:"limit"`
+	ActorID     int    `gorm:"NOT NULL;default:0;comment:设置的管理员" json:"actorId"`
+
+	BaseModel
+}
+
+func (QuotaOverride) TableName() string {
+	return "quota_override"
+}
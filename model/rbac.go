@@ -0,0 +1,38 @@
+package model
+
+// Role 角色，权限格式见 pkg/permission
+type Role struct {
+	Name        string   `gorm:"type:VARCHAR(64) NOT NULL;uniqueIndex;comment:角色名" json:"name"`
+	Description string   `gorm:"type:VARCHAR(255) NOT NULL;default:'';comment:描述" json:"description"`
+	Permissions []string `gorm:"-" json:"permissions"`
+
+	BaseModel
+}
+
+func (Role) TableName() string {
+	return "role"
+}
+
+// RolePermission 角色拥有的权限
+type RolePermission struct {
+	RoleID     ID     `gorm:"NOT NULL;uniqueIndex:idx_role_permission;comment:角色主键" json:"roleId"`
+	Permission string `gorm:"type:VARCHAR(128) NOT NULL;uniqueIndex:idx_role_permission;comment:权限" json:"permission"`
+
+	BaseModel
+}
+
+func (RolePermission) TableName() string {
+	return "role_permission"
+}
+
+// UserRole 用户与角色的关联
+type UserRole struct {
+	UserID int `gorm:"NOT NULL;uniqueIndex:idx_user_role;comment:用户主键" json:"userId"`
+	RoleID ID  `gorm:"NOT NULL;uniqueIndex:idx_user_role;index;comment:角色主键" json:"roleId"`
+
+	BaseModel
+}
+
+func (UserRole) TableName() string {
+	return "user_role"
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"sync"
+
+	"template/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 按 APP_REDIS_URL 创建的客户端，会话与限流使用 redis 存储时共用同一个连接池
+var Redis = sync.OnceValues(func() (*redis.Client, error) {
+	opts, err := redis.ParseURL(config.Config.RedisURL)
+	if err != nil {
+		return nil, err
+	}
+	return redis.NewClient(opts), nil
+})
@@ -15,8 +15,12 @@ func (Resource) TableName() string {
 	return "resource"
 }
 
+func (Resource) OwnerColumn() string {
+	return "user_id"
+}
+
 func (e *Resource) BeforeCreate(_ *gorm.DB) error {
-	return nil
+	return e.AssignID(IDSnowflake)
 }
 
 func (e *Resource) BeforeUpdate(_ *gorm.DB) error {
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 短信验证码的用途
+const (
+	SMSLogin = "login"
+	SMSBind  = "bind"
+)
+
+// SMSCode 短信验证码，手机号及验证码只保存盲索引
+type SMSCode struct {
+	PhoneIndex string     `gorm:"type:CHAR(64) NOT NULL;index;comment:手机号盲索引" json:"-"`
+	Purpose    string     `gorm:"type:VARCHAR(16) NOT NULL;comment:用途" json:"purpose"`
+	Hash       string     `gorm:"type:CHAR(64) NOT NULL;comment:验证码哈希" json:"-"`
+	IP         string     `gorm:"type:VARCHAR(64) NOT NULL;comment:请求IP" json:"ip"`
+	Attempts   int        `gorm:"NOT NULL;default:0;comment:错误次数" json:"attempts"`
+	ExpiresAt  time.Time  `gorm:"type:DATETIME(3);NOT NULL;comment:过期时间" json:"expiresAt"`
+	UsedAt     *time.Time `gorm:"type:DATETIME(3);NULL;comment:使用时间" json:"usedAt"`
+
+	BaseModel
+}
+
+func (SMSCode) TableName() string {
+	return "sms_code"
+}
+
+func (e *SMSCode) BeforeCreate(_ *gorm.DB) error {
+	return e.AssignID(IDSnowflake)
+}
+
+// SMSSendCounter 按手机号记录最近发送时间及当日发送次数，用于在并发请求下限制发送频率
+type SMSSendCounter struct {
+	PhoneIndex string    `gorm:"type:CHAR(64) NOT NULL;uniqueIndex;comment:手机号盲索引" json:"-"`
+	LastSentAt time.Time `gorm:"type:DATETIME(3);NOT NULL;comment:最近发送时间" json:"lastSentAt"`
+	Day        string    `gorm:"type:CHAR(10) NOT NULL;comment:统计日期" json:"day"`
+	DayCount   int       `gorm:"NOT NULL;default:0;comment:当日发送次数" json:"dayCount"`
+
+	BaseModel
+}
+
+func (SMSSendCounter) TableName() string {
+	return "sms_send_counter"
+}
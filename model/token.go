@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken 已签发的刷新令牌，同一次登录轮换出的令牌属于同一 Family
+type RefreshToken struct {
+	UserID    int        `gorm:"NOT NULL;index;comment:用户主键" json:"userId"`
+	Family    string     `gorm:"type:VARCHAR(36) NOT NULL;index;comment:令牌族，与登录会话ID相同" json:"family"`
+	ExpiresAt time.Time  `gorm:"type:DATETIME(3);NOT NULL;index;comment:过期时间" json:"expiresAt"`
+	UsedAt    *time.Time `gorm:"type:DATETIME(3);NULL;comment:轮换时间" json:"usedAt"`
+	RevokedAt *time.Time `gorm:"type:DATETIME(3);NULL;comment:注销时间" json:"revokedAt"`
+
+	StrBaseModel
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_token"
+}
+
+func (e *RefreshToken) BeforeCreate(_ *gorm.DB) error {
+	return e.AssignID(IDULID)
+}
+
+// RevokedToken 在过期前被注销的访问令牌
+type RevokedToken struct {
+	ID        string    `gorm:"primaryKey;type:VARCHAR(36);NOT NULL;comment:令牌ID" json:"id"`
+	ExpiresAt time.Time `gorm:"type:DATETIME(3);NOT NULL;index;comment:令牌过期时间" json:"expiresAt"`
+}
+
+func (RevokedToken) TableName() string {
+	return "revoked_token"
+}
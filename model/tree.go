@@ -0,0 +1,206 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTreeCycle = errors.New("model: cannot move a node into its own subtree")
+
+// TreeModel 物化路径形式的树结构，与 BaseModel 一同嵌入模型中使用
+// Path 为祖先节点主键组成的路径，根节点为 "/"，节点 1 的子节点为 "/1/"
+type TreeModel struct {
+	ParentID ID     `gorm:"NOT NULL;default:0;index;comment:父节点主键" json:"parentId"`
+	Path     string `gorm:"type:VARCHAR(512) NOT NULL;default:'/';index;comment:祖先路径" json:"path"`
+	Depth    int    `gorm:"NOT NULL;default:0;comment:深度" json:"depth"`
+}
+
+// TreeNode 嵌入了 BaseModel 与 TreeModel 的模型
+type TreeNode interface {
+	PrimaryKey() ID
+	Tree() *TreeModel
+}
+
+func (m *BaseModel) PrimaryKey() ID {
+	return m.ID
+}
+
+func (t *TreeModel) Tree() *TreeModel {
+	return t
+}
+
+// AncestorIDs 从根到父节点的主键
+func (t *TreeModel) AncestorIDs() []ID {
+	var ids []ID
+	for _, s := range strings.Split(strings.Trim(t.Path, "/"), "/") {
+		if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+			ids = append(ids, ID(id))
+		}
+	}
+	return ids
+}
+
+// subtreePrefix 节点所有后代的路径前缀
+func subtreePrefix(node TreeNode) string {
+	return node.Tree().Path + node.PrimaryKey().String() + "/"
+}
+
+func newNode(node TreeNode) TreeNode {
+	return reflect.New(reflect.TypeOf(node).Elem()).Interface().(TreeNode)
+}
+
+// lockNode 在事务中锁定并读取节点
+func lockNode(tx *gorm.DB, node TreeNode, id ID) (TreeNode, error) {
+	locked := newNode(node)
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(locked, clause.Eq{Column: clause.PrimaryColumn, Value: id}).Error
+	return locked, err
+}
+
+// TreeInsert 将节点插入到 parentID 下，parentID 为 0 时作为根节点
+func TreeInsert(db *gorm.DB, node TreeNode, parentID ID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		t := node.Tree()
+		t.ParentID, t.Path, t.Depth = 0, "/", 0
+		if parentID != 0 {
+			parent, err := lockNode(tx, node, parentID)
+			if err != nil {
+				return err
+			}
+			t.ParentID = parentID
+			t.Path = subtreePrefix(parent)
+			t.Depth = parent.Tree().Depth + 1
+		}
+		return tx.Create(node).Error
+	})
+}
+
+// TreeMove 将节点及其整个子树移动到 parentID 下，parentID 为 0 时移动为根节点
+func TreeMove(db *gorm.DB, node TreeNode, parentID ID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockNode(tx, node, node.PrimaryKey())
+		if err != nil {
+			return err
+		}
+		oldPrefix := subtreePrefix(current)
+
+		moved := TreeModel{Path: "/"}
+		if parentID != 0 {
+			if parentID == current.PrimaryKey() {
+				return ErrTreeCycle
+			}
+			parent, err := lockNode(tx, node, parentID)
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(parent.Tree().Path, oldPrefix) {
+				return ErrTreeCycle
+			}
+			moved = TreeModel{ParentID: parentID, Path: subtreePrefix(parent), Depth: parent.Tree().Depth + 1}
+		}
+		newPrefix := moved.Path + current.PrimaryKey().String() + "/"
+		delta := moved.Depth - current.Tree().Depth
+
+		if err := tx.Model(newNode(node)).Where("path LIKE ?", oldPrefix+"%").Updates(map[string]any{
+			"path":  gorm.Expr("CONCAT(?, SUBSTRING(path, ?))", newPrefix, len(oldPrefix)+1),
+			"depth": gorm.Expr("depth + ?", delta),
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(current).Updates(map[string]any{
+			"parent_id": moved.ParentID,
+			"path":      moved.Path,
+			"depth":     moved.Depth,
+		}).Error; err != nil {
+			return err
+		}
+		*node.Tree() = moved
+		return nil
+	})
+}
+
+// TreeAncestors 查询节点的所有祖先，按深度从根开始排列
+func TreeAncestors(db *gorm.DB, node TreeNode, dest any) error {
+	ids := node.Tree().AncestorIDs()
+	if len(ids) == 0 {
+		return db.Model(node).Where("1 = 0").Find(dest).Error
+	}
+	return db.Model(node).Where(clause.IN{Column: clause.PrimaryColumn, Values: toAny(ids)}).Order("depth").Find(dest).Error
+}
+
+// TreeDescendants 查询节点的后代，maxDepth 为相对深度，小于等于 0 时不限制
+func TreeDescendants(db *gorm.DB, node TreeNode, maxDepth int, dest any) error {
+	return db.Model(node).Scopes(TreeSubtree(node, maxDepth)).Order("depth").Find(dest).Error
+}
+
+// TreeSubtree 限定为节点的后代(不含节点本身)
+func TreeSubtree(node TreeNode, maxDepth int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("path LIKE ?", subtreePrefix(node)+"%")
+		if maxDepth > 0 {
+			db = db.Where("depth <= ?", node.Tree().Depth+maxDepth)
+		}
+		return db
+	}
+}
+
+func toAny[T any](s []T) []any {
+	out := make([]any, len(s))
+	for i, v := range s {
+		out[i] = v
+	}
+	return out
+}
+
+// Tree 嵌套的树结构，序列化时子节点以 children 字段附加在节点字段之后
+type Tree[T TreeNode] struct {
+	Node     T
+	Children []*Tree[T]
+}
+
+func (t *Tree[T]) MarshalJSON() ([]byte, error) {
+	node, err := json.Marshal(t.Node)
+	if err != nil {
+		return nil, err
+	}
+	children, err := json.Marshal(t.Children)
+	if err != nil {
+		return nil, err
+	}
+	if t.Children == nil {
+		children = []byte("[]")
+	}
+
+	node = bytes.TrimSuffix(bytes.TrimSpace(node), []byte("}"))
+	if !bytes.HasSuffix(node, []byte("{")) {
+		node = append(node, ',')
+	}
+	node = append(node, `"children":`...)
+	node = append(node, children...)
+	return append(node, '}'), nil
+}
+
+// BuildTree 将节点列表组装为嵌套树，父节点不在列表中的节点作为根，保持列表原有顺序
+func BuildTree[T TreeNode](nodes []T) []*Tree[T] {
+	trees := make(map[ID]*Tree[T], len(nodes))
+	for _, node := range nodes {
+		trees[node.PrimaryKey()] = &Tree[T]{Node: node}
+	}
+
+	var roots []*Tree[T]
+	for _, node := range nodes {
+		tree := trees[node.PrimaryKey()]
+		if parent, ok := trees[node.Tree().ParentID]; ok && node.Tree().ParentID != node.PrimaryKey() {
+			parent.Children = append(parent.Children, tree)
+		} else {
+			roots = append(roots, tree)
+		}
+	}
+	return roots
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func category(id, parent ID, path string, depth int, name string) *Category {
+	c := &Category{Name: name, TreeModel: TreeModel{ParentID: parent, Path: path, Depth: depth}}
+	c.ID = id
+	return c
+}
+
+func TestBuildTree(t *testing.T) {
+	nodes := []*Category{
+		category(1, 0, "/", 0, "a"),
+		category(2, 1, "/1/", 1, "b"),
+		category(3, 2, "/1/2/", 2, "c"),
+		category(4, 1, "/1/", 1, "d"),
+		category(5, 9, "/9/", 1, "orphan"),
+	}
+	trees := BuildTree(nodes)
+	if len(trees) != 2 || trees[0].Node.Name != "a" || trees[1].Node.Name != "orphan" {
+		t.Fatalf("unexpected roots %+v", trees)
+	}
+	if len(trees[0].Children) != 2 || trees[0].Children[0].Children[0].Node.Name != "c" {
+		t.Fatalf("unexpected children %+v", trees[0].Children)
+	}
+
+	data, err := json.Marshal(trees[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid json %s: %v", data, err)
+	}
+	if decoded["name"] != "a" || decoded["id"] != "1" {
+		t.Fatalf("node fields missing: %s", data)
+	}
+	children := decoded["children"].([]any)
+	if len(children) != 2 || children[1].(map[string]any)["children"] == nil {
+		t.Fatalf("unexpected children json: %s", data)
+	}
+}
+
+func TestTreeModel_AncestorIDs(t *testing.T) {
+	if ids := (&TreeModel{Path: "/1/22/333/"}).AncestorIDs(); !reflect.DeepEqual(ids, []ID{1, 22, 333}) {
+		t.Fatalf("unexpected ancestors %v", ids)
+	}
+	if ids := (&TreeModel{Path: "/"}).AncestorIDs(); len(ids) != 0 {
+		t.Fatalf("root should have no ancestors, got %v", ids)
+	}
+}
+
+func TestTreeSubtree(t *testing.T) {
+	db := dryRunDB(t)
+	node := category(2, 1, "/1/", 1, "b")
+
+	stmt := db.Scopes(TreeSubtree(node, 2)).Find(&[]Category{}).Statement
+	sql := stmt.SQL.String()
+	if !strings.Contains(sql, "path LIKE ?") || !strings.Contains(sql, "depth <= ?") {
+		t.Fatalf("unexpected sql %s", sql)
+	}
+	if stmt.Vars[0] != "/1/2/%" || stmt.Vars[1] != 3 {
+		t.Fatalf("unexpected vars %v", stmt.Vars)
+	}
+}
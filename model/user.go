@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// User 用户账号，密码保存为 pkg/password 计算的哈希
+type User struct {
+	Username string `gorm:"type:VARCHAR(64) NOT NULL;uniqueIndex;comment:用户名" json:"username"`
+	Password string `gorm:"type:VARCHAR(255) NOT NULL;comment:密码哈希" json:"-"`
+	Level    int    `gorm:"NOT NULL;default:1;comment:权限等级" json:"level"`
+
+	Email           *string    `gorm:"type:VARCHAR(191) NULL;uniqueIndex;comment:邮箱" json:"email"`
+	EmailVerifiedAt *time.Time `gorm:"type:DATETIME(3);NULL;comment:邮箱验证时间" json:"emailVerifiedAt"`
+
+	Phone      string  `gorm:"type:VARCHAR(255) NOT NULL;default:'';serializer:encrypt;comment:手机号" json:"phone"`
+	PhoneIndex *string `gorm:"type:CHAR(64) NULL;uniqueIndex;comment:手机号盲索引" json:"-"`
+
+	TOTPSecret  string `gorm:"type:VARCHAR(255) NOT NULL;default:'';serializer:encrypt;comment:两步验证密钥" json:"-"`
+	TOTPEnabled bool   `gorm:"NOT NULL;default:false;comment:是否启用两步验证" json:"totpEnabled"`
+	TOTPCounter int64  `gorm:"NOT NULL;default:0;comment:最后使用的验证码时间步，用于防止重放" json:"-"`
+
+	BaseModel
+}
+
+func (User) TableName() string {
+	return "user"
+}
+
+// RecoveryCode 两步验证的恢复码，只保存盲索引
+type RecoveryCode struct {
+	UserID int        `gorm:"NOT NULL;index;comment:用户主键" json:"userId"`
+	Hash   string     `gorm:"type:CHAR(64) NOT NULL;index;comment:恢复码哈希" json:"-"`
+	UsedAt *time.Time `gorm:"type:DATETIME(3);NULL;comment:使用时间" json:"usedAt"`
+
+	BaseModel
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_code"
+}
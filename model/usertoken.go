@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 一次性令牌的用途
+const (
+	TokenPasswordReset = "password-reset"
+	TokenEmailVerify   = "email-verify"
+)
+
+// UserToken 通过邮件发送的一次性令牌，只保存 SHA-256 哈希
+type UserToken struct {
+	UserID    int        `gorm:"NOT NULL;index;comment:用户主键" json:"userId"`
+	Purpose   string     `gorm:"type:VARCHAR(32) NOT NULL;comment:用途" json:"purpose"`
+	Hash      string     `gorm:"type:CHAR(64) NOT NULL;uniqueIndex;comment:令牌哈希" json:"-"`
+	Email     string     `gorm:"type:VARCHAR(191) NOT NULL;comment:发送到的邮箱" json:"email"`
+	ExpiresAt time.Time  `gorm:"type:DATETIME(3);NOT NULL;comment:过期时间" json:"expiresAt"`
+	UsedAt    *time.Time `gorm:"type:DATETIME(3);NULL;comment:使用时间" json:"usedAt"`
+
+	BaseModel
+}
+
+func (UserToken) TableName() string {
+	return "user_token"
+}
+
+func (e *UserToken) BeforeCreate(_ *gorm.DB) error {
+	return e.AssignID(IDSnowflake)
+}
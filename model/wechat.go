@@ -0,0 +1,16 @@
+package model
+
+// WechatBinding 小程序 openid 与本地用户的绑定，session_key 加密保存，用于解密后续提交的加密数据
+type WechatBinding struct {
+	UserID     int    `gorm:"NOT NULL;index;comment:用户主键" json:"userId"`
+	AppID      string `gorm:"type:VARCHAR(32) NOT NULL;uniqueIndex:idx_app_openid;comment:小程序appid" json:"appId"`
+	OpenID     string `gorm:"type:VARCHAR(64) NOT NULL;uniqueIndex:idx_app_openid;comment:openid" json:"openId"`
+	UnionID    string `gorm:"type:VARCHAR(64) NOT NULL;default:'';index;comment:unionid" json:"unionId"`
+	SessionKey string `gorm:"type:VARCHAR(255) NOT NULL;default:'';serializer:encrypt;comment:会话密钥" json:"-"`
+
+	BaseModel
+}
+
+func (WechatBinding) TableName() string {
+	return "wechat_binding"
+}
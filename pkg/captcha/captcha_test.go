@@ -0,0 +1,80 @@
+package captcha
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	c, err := New(Text, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Answer) != 5 || c.Display != c.Answer || strings.ContainsAny(c.Answer, "01IOL") {
+		t.Fatalf("unexpected text challenge %+v", c)
+	}
+
+	for range 100 {
+		c, err := New(Math, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expr := strings.TrimSuffix(c.Display, "=?")
+		i := strings.IndexAny(expr, "+-x")
+		a, _ := strconv.Atoi(expr[:i])
+		b, _ := strconv.Atoi(expr[i+1:])
+		want := map[byte]int{'+': a + b, '-': a - b, 'x': a * b}[expr[i]]
+		if c.Answer != strconv.Itoa(want) || want < 0 {
+			t.Fatalf("wrong answer for %q: %s", c.Display, c.Answer)
+		}
+	}
+
+	if _, err := New("other", 4); err == nil {
+		t.Fatal("unknown kind accepted")
+	}
+}
+
+func TestRender(t *testing.T) {
+	for _, text := range []string{"AB3XZ", "45+7=?"} {
+		data, err := Render(text, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != 160 || b.Dy() != 60 {
+			t.Fatalf("unexpected size %v", b)
+		}
+	}
+	for r := range glyphs {
+		for _, row := range glyphs[r] {
+			if len(row) != glyphWidth {
+				t.Fatalf("glyph %q has row %q", r, row)
+			}
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	s.Set(ctx, "a", "42", time.Now().Add(time.Minute))
+	s.Set(ctx, "b", "42", time.Now().Add(-time.Second))
+
+	if answer, ok, _ := s.Take(ctx, "a"); !ok || answer != "42" {
+		t.Fatal("answer not found")
+	}
+	if _, ok, _ := s.Take(ctx, "a"); ok {
+		t.Fatal("captcha used twice")
+	}
+	if _, ok, _ := s.Take(ctx, "b"); ok {
+		t.Fatal("expired captcha accepted")
+	}
+}
@@ -0,0 +1,117 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Store 保存验证码答案，Take 读取后立即删除，保证每个验证码只能校验一次
+type Store interface {
+	Set(ctx context.Context, id, answer string, expiresAt time.Time) error
+	// Take 取出答案，不存在或已过期时 ok 为 false
+	Take(ctx context.Context, id string) (answer string, ok bool, err error)
+}
+
+type memoryEntry struct {
+	answer    string
+	expiresAt time.Time
+}
+
+// MemoryStore 进程内存储，仅适用于单实例部署及测试
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	calls   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Set(_ context.Context, id, answer string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls%1024 == 0 {
+		now := time.Now()
+		for key, e := range s.entries {
+			if !now.Before(e.expiresAt) {
+				delete(s.entries, key)
+			}
+		}
+	}
+	s.entries[id] = memoryEntry{answer: answer, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *MemoryStore) Take(_ context.Context, id string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[id]
+	if !ok {
+		return "", false, nil
+	}
+	delete(s.entries, id)
+	if !time.Now().Before(e.expiresAt) {
+		return "", false, nil
+	}
+	return e.answer, true, nil
+}
+
+// Record 数据库中的验证码记录
+type Record struct {
+	ID        string    `gorm:"primaryKey;type:VARCHAR(64);NOT NULL;comment:验证码ID"`
+	Answer    string    `gorm:"type:VARCHAR(32) NOT NULL;comment:答案"`
+	ExpiresAt time.Time `gorm:"type:DATETIME(3);NOT NULL;index;comment:过期时间"`
+}
+
+func (Record) TableName() string {
+	return "captcha"
+}
+
+// GormStore 使用数据库存储，多实例部署时共享
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// Migrate 创建验证码表
+func (s *GormStore) Migrate() error {
+	return s.db.AutoMigrate(&Record{})
+}
+
+func (s *GormStore) Set(ctx context.Context, id, answer string, expiresAt time.Time) error {
+	return s.db.WithContext(ctx).Create(&Record{ID: id, Answer: answer, ExpiresAt: expiresAt}).Error
+}
+
+func (s *GormStore) Take(ctx context.Context, id string) (string, bool, error) {
+	var record Record
+	err := s.db.WithContext(ctx).Take(&record, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	// 并发校验同一验证码时只有删除成功的一方有效
+	result := s.db.WithContext(ctx).Delete(&Record{}, "id = ?", id)
+	if result.Error != nil {
+		return "", false, result.Error
+	}
+	if result.RowsAffected == 0 || !time.Now().Before(record.ExpiresAt) {
+		return "", false, nil
+	}
+	return record.Answer, true, nil
+}
+
+// GC 删除已过期的记录
+func (s *GormStore) GC(ctx context.Context) error {
+	return s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&Record{}).Error
+}
@@ -0,0 +1,95 @@
+// Package csrf 提供与会话绑定的 CSRF 令牌及 Origin/Referer 校验
+// 会话中保存原始令牌，每次下发时与随机数异或后编码，避免响应压缩时通过长度推测令牌
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const tokenLen = 32
+
+var (
+	// ErrOrigin 请求来源不受信任
+	ErrOrigin = errors.New("csrf: untrusted origin")
+)
+
+// NewToken 生成保存在会话中的原始令牌
+func NewToken() (string, error) {
+	raw := make([]byte, tokenLen)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// Mask 生成下发给客户端的令牌，同一原始令牌每次结果不同
+func Mask(token string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != tokenLen {
+		return "", errors.New("csrf: invalid token")
+	}
+	out := make([]byte, 2*tokenLen)
+	if _, err := rand.Read(out[:tokenLen]); err != nil {
+		return "", err
+	}
+	for i := range raw {
+		out[tokenLen+i] = out[i] ^ raw[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// Verify 客户端提交的 masked 是否由原始令牌 token 生成
+func Verify(token, masked string) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != tokenLen {
+		return false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(masked)
+	if err != nil || len(data) != 2*tokenLen {
+		return false
+	}
+	got := make([]byte, tokenLen)
+	for i := range got {
+		got[i] = data[i] ^ data[tokenLen+i]
+	}
+	return subtle.ConstantTimeCompare(got, raw) == 1
+}
+
+// CheckOrigin 校验 Origin 请求头，没有时校验 Referer，均没有时视为非浏览器请求放行
+// 来源与请求的 Host 相同，或与 trusted 中的某项（如 https://app.example.com）完全相同时通过
+func CheckOrigin(r *http.Request, trusted []string) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		referer := r.Header.Get("Referer")
+		if referer == "" {
+			return nil
+		}
+		u, err := url.Parse(referer)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return ErrOrigin
+		}
+		origin = u.Scheme + "://" + u.Host
+	}
+	if origin == "null" {
+		return ErrOrigin
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return ErrOrigin
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return nil
+	}
+	for _, t := range trusted {
+		if strings.EqualFold(strings.TrimRight(t, "/"), u.Scheme+"://"+u.Host) {
+			return nil
+		}
+	}
+	return ErrOrigin
+}
@@ -0,0 +1,54 @@
+package csrf
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMaskVerify(t *testing.T) {
+	token, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := Mask(token)
+	b, _ := Mask(token)
+	if a == b {
+		t.Fatal("masked tokens should differ")
+	}
+	if !Verify(token, a) || !Verify(token, b) {
+		t.Fatal("masked token rejected")
+	}
+	other, _ := NewToken()
+	if Verify(other, a) || Verify(token, token) || Verify(token, "") {
+		t.Fatal("invalid token accepted")
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	trusted := []string{"https://app.example.com/"}
+	cases := []struct {
+		origin, referer string
+		ok              bool
+	}{
+		{"", "", true},
+		{"https://api.example.com", "", true},
+		{"https://app.example.com", "", true},
+		{"https://evil.example.com", "", false},
+		{"null", "", false},
+		{"", "https://app.example.com/page?x=1", true},
+		{"", "https://evil.com/page", false},
+		{"", "not a url", false},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("POST", "https://api.example.com/api/user/login", nil)
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if tc.referer != "" {
+			r.Header.Set("Referer", tc.referer)
+		}
+		if err := CheckOrigin(r, trusted); (err == nil) != tc.ok {
+			t.Errorf("origin %q referer %q: %v", tc.origin, tc.referer, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 密文格式: enc1:<密钥ID>:<base64(nonce|密文)>，不带前缀的值视为历史明文
+const prefix = "enc1:"
+
+var (
+	ErrUnknownKey = errors.New("fieldcrypt: unknown key id")
+	ErrMalformed  = errors.New("fieldcrypt: malformed ciphertext")
+)
+
+// Keyring 字段加密密钥环，当前密钥用于加密，其余密钥仅用于解密旧数据
+type Keyring struct {
+	aeads    map[string]cipher.AEAD
+	current  string
+	indexKey []byte
+}
+
+// NewKeyring 创建密钥环，密钥须为16/24/32字节，indexKey 用于生成盲索引
+func NewKeyring(keys map[string][]byte, current string, indexKey []byte) (*Keyring, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("fieldcrypt: current key %q not found", current)
+	}
+	if len(indexKey) < 16 {
+		return nil, errors.New("fieldcrypt: blind index key must be at least 16 bytes")
+	}
+	k := &Keyring{aeads: make(map[string]cipher.AEAD), current: current, indexKey: indexKey}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("fieldcrypt: invalid key id %q", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("fieldcrypt: key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.aeads[id] = aead
+	}
+	return k, nil
+}
+
+// ParseKeys 解析 "id:base64|id:base64" 格式的密钥配置
+func ParseKeys(spec string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, item := range strings.Split(spec, "|") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("fieldcrypt: invalid key spec %q", item)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("fieldcrypt: key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
+
+// CurrentKeyID 当前用于加密的密钥ID
+func (k *Keyring) CurrentKeyID() string {
+	return k.current
+}
+
+// Encrypt 使用当前密钥加密
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	aead := k.aeads[k.current]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	// 密钥ID作为附加数据，防止密文被替换到其他密钥下
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(k.current))
+	return prefix + k.current + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 按密文中的密钥ID解密，不带前缀的值原样返回
+func (k *Keyring) Decrypt(value string) ([]byte, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return []byte(value), nil
+	}
+	id, encoded, ok := strings.Cut(value[len(prefix):], ":")
+	if !ok {
+		return nil, ErrMalformed
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(id))
+}
+
+// KeyID 返回密文使用的密钥ID，明文返回空字符串
+func KeyID(value string) string {
+	if !strings.HasPrefix(value, prefix) {
+		return ""
+	}
+	id, _, _ := strings.Cut(value[len(prefix):], ":")
+	return id
+}
+
+// BlindIndex 生成确定性的盲索引，用于对加密字段做等值查询，purpose 区分不同字段
+func (k *Keyring) BlindIndex(purpose, value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
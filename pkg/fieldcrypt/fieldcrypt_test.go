@@ -0,0 +1,102 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKeyring(t *testing.T, current string) *Keyring {
+	keys, err := ParseKeys("1:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=|2:ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewKeyring(keys, current, []byte("blind-index-key-0123456789"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestKeyring_RoundTrip(t *testing.T) {
+	k := testKeyring(t, "1")
+	enc, err := k.Encrypt([]byte("13800138000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enc, "enc1:1:") || strings.Contains(enc, "13800138000") {
+		t.Fatalf("unexpected ciphertext %q", enc)
+	}
+	again, _ := k.Encrypt([]byte("13800138000"))
+	if enc == again {
+		t.Fatal("ciphertext should be randomized")
+	}
+	plain, err := k.Decrypt(enc)
+	if err != nil || string(plain) != "13800138000" {
+		t.Fatalf("decrypt failed: %q %v", plain, err)
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old := testKeyring(t, "1")
+	enc, _ := old.Encrypt([]byte("2021000001"))
+
+	rotated := testKeyring(t, "2")
+	plain, err := rotated.Decrypt(enc)
+	if err != nil || string(plain) != "2021000001" {
+		t.Fatalf("decrypt with rotated keyring failed: %q %v", plain, err)
+	}
+	reenc, _ := rotated.Encrypt(plain)
+	if KeyID(reenc) != "2" || KeyID(enc) != "1" {
+		t.Fatalf("unexpected key ids %q %q", KeyID(enc), KeyID(reenc))
+	}
+
+	keys, _ := ParseKeys("2:ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=")
+	only2, _ := NewKeyring(keys, "2", []byte("blind-index-key-0123456789"))
+	if _, err := only2.Decrypt(enc); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestKeyring_Tampered(t *testing.T) {
+	k := testKeyring(t, "1")
+	enc, _ := k.Encrypt([]byte("secret"))
+	// 替换密钥ID后附加数据不匹配，解密应失败
+	if _, err := k.Decrypt(strings.Replace(enc, "enc1:1:", "enc1:2:", 1)); err == nil {
+		t.Fatal("expected error for swapped key id")
+	}
+	if _, err := k.Decrypt("enc1:1"); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("expected ErrMalformed, got %v", err)
+	}
+	plain, err := k.Decrypt("legacy plaintext")
+	if err != nil || !bytes.Equal(plain, []byte("legacy plaintext")) {
+		t.Fatalf("plaintext passthrough failed: %q %v", plain, err)
+	}
+}
+
+func TestKeyring_BlindIndex(t *testing.T) {
+	k1 := testKeyring(t, "1")
+	k2 := testKeyring(t, "2")
+	if k1.BlindIndex("phone", "13800138000") != k2.BlindIndex("phone", "13800138000") {
+		t.Fatal("blind index should not depend on encryption key")
+	}
+	if k1.BlindIndex("phone", "13800138000") == k1.BlindIndex("student", "13800138000") {
+		t.Fatal("blind index should differ between purposes")
+	}
+	if len(k1.BlindIndex("phone", "x")) != 64 {
+		t.Fatal("unexpected blind index length")
+	}
+}
+
+func TestNewKeyring_Invalid(t *testing.T) {
+	if _, err := NewKeyring(map[string][]byte{"1": make([]byte, 32)}, "2", make([]byte, 32)); err == nil {
+		t.Fatal("expected error for missing current key")
+	}
+	if _, err := NewKeyring(map[string][]byte{"1": make([]byte, 7)}, "1", make([]byte, 32)); err == nil {
+		t.Fatal("expected error for invalid key size")
+	}
+	if _, err := ParseKeys("nocolon"); err == nil {
+		t.Fatal("expected error for invalid spec")
+	}
+}
@@ -0,0 +1,119 @@
+package idgen
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnowflake_UniqueAndOrdered(t *testing.T) {
+	sf, err := NewSnowflake(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	seen := make(map[int64]struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5000; j++ {
+				id, err := sf.Next()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if _, ok := seen[id]; ok {
+					t.Errorf("duplicate id %d", id)
+				}
+				seen[id] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	prev, _ := sf.Next()
+	for i := 0; i < 1000; i++ {
+		id, _ := sf.Next()
+		if id <= prev {
+			t.Fatalf("id not increasing: %d after %d", id, prev)
+		}
+		prev = id
+	}
+	if SnowflakeNode(prev) != 7 {
+		t.Fatalf("unexpected node %d", SnowflakeNode(prev))
+	}
+	if d := time.Since(SnowflakeTime(prev)); d < 0 || d > time.Minute {
+		t.Fatalf("unexpected timestamp %v", SnowflakeTime(prev))
+	}
+}
+
+func TestSnowflake_NodeRange(t *testing.T) {
+	if _, err := NewSnowflake(MaxNode + 1); err == nil {
+		t.Fatal("expected error for node out of range")
+	}
+	if _, err := NewSnowflake(-1); err == nil {
+		t.Fatal("expected error for negative node")
+	}
+}
+
+func TestSnowflake_ClockBackwards(t *testing.T) {
+	sf, _ := NewSnowflake(1)
+	now := time.Now()
+	sf.now = func() time.Time { return now }
+	if _, err := sf.Next(); err != nil {
+		t.Fatal(err)
+	}
+	sf.now = func() time.Time { return now.Add(-time.Second) }
+	if _, err := sf.Next(); err != ErrClockBackwards {
+		t.Fatalf("expected ErrClockBackwards, got %v", err)
+	}
+}
+
+func TestULID_FormatAndOrder(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`)
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = NewULID()
+		if !re.MatchString(ids[i]) {
+			t.Fatalf("invalid ulid %q", ids[i])
+		}
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Fatal("ulids generated in sequence are not sorted")
+	}
+}
+
+func TestULID_Encode(t *testing.T) {
+	var max [16]byte
+	for i := range max {
+		max[i] = 0xff
+	}
+	if got := encodeULID(max); got != "7ZZZZZZZZZZZZZZZZZZZZZZZZZ" {
+		t.Fatalf("unexpected max ulid %q", got)
+	}
+	if got := encodeULID([16]byte{}); got != "00000000000000000000000000" {
+		t.Fatalf("unexpected zero ulid %q", got)
+	}
+}
+
+func TestUUIDv7_Format(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := make(map[string]struct{})
+	for i := 0; i < 1000; i++ {
+		id := NewUUIDv7()
+		if !re.MatchString(id) {
+			t.Fatalf("invalid uuidv7 %q", id)
+		}
+		if _, ok := seen[id]; ok {
+			t.Fatalf("duplicate uuid %q", id)
+		}
+		seen[id] = struct{}{}
+	}
+}
@@ -0,0 +1,81 @@
+package idgen
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 雪花ID布局: 1位符号位 | 41位毫秒时间戳 | 10位节点号 | 12位序列号
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+
+	MaxNode     = 1<<nodeBits - 1
+	maxSequence = 1<<sequenceBits - 1
+)
+
+// Epoch 雪花ID的起始时间(2024-01-01 UTC)，上线后不可修改
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var ErrClockBackwards = errors.New("idgen: clock moved backwards")
+
+// Snowflake 雪花ID生成器，并发安全
+type Snowflake struct {
+	mu       sync.Mutex
+	node     int64
+	lastMs   int64
+	sequence int64
+	now      func() time.Time
+}
+
+// NewSnowflake 创建指定节点号的生成器，多实例部署时各实例节点号必须不同
+func NewSnowflake(node int64) (*Snowflake, error) {
+	if node < 0 || node > MaxNode {
+		return nil, fmt.Errorf("idgen: snowflake node %d out of range [0, %d]", node, MaxNode)
+	}
+	return &Snowflake{node: node, now: time.Now}, nil
+}
+
+// Next 生成下一个ID，同一毫秒内序列号耗尽时会等待到下一毫秒
+func (s *Snowflake) Next() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := s.now().Sub(Epoch).Milliseconds()
+	if ms < s.lastMs {
+		// 小幅回拨时等待时钟追上，否则报错以免产生重复ID
+		if s.lastMs-ms > 5 {
+			return 0, ErrClockBackwards
+		}
+		for ms < s.lastMs {
+			time.Sleep(time.Millisecond)
+			ms = s.now().Sub(Epoch).Milliseconds()
+		}
+	}
+
+	if ms == s.lastMs {
+		s.sequence = (s.sequence + 1) & maxSequence
+		if s.sequence == 0 {
+			for ms <= s.lastMs {
+				ms = s.now().Sub(Epoch).Milliseconds()
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.lastMs = ms
+
+	return ms<<(nodeBits+sequenceBits) | s.node<<sequenceBits | s.sequence, nil
+}
+
+// SnowflakeTime 解析雪花ID中的生成时间
+func SnowflakeTime(id int64) time.Time {
+	return Epoch.Add(time.Duration(id>>(nodeBits+sequenceBits)) * time.Millisecond)
+}
+
+// SnowflakeNode 解析雪花ID中的节点号
+func SnowflakeNode(id int64) int64 {
+	return id >> sequenceBits & MaxNode
+}
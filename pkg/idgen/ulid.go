@@ -0,0 +1,99 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Crockford base32 字母表，ULID按字典序即按时间排序
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var ulidState struct {
+	mu     sync.Mutex
+	lastMs int64
+	last   [10]byte
+}
+
+// NewULID 生成26位ULID，同一毫秒内随机部分单调递增
+func NewULID() string {
+	return encodeULID(newULID(time.Now()))
+}
+
+func newULID(t time.Time) [16]byte {
+	ms := t.UnixMilli()
+
+	ulidState.mu.Lock()
+	defer ulidState.mu.Unlock()
+
+	var entropy [10]byte
+	if ms == ulidState.lastMs {
+		entropy = ulidState.last
+		for i := len(entropy) - 1; i >= 0; i-- {
+			entropy[i]++
+			if entropy[i] != 0 {
+				break
+			}
+		}
+	} else {
+		_, _ = rand.Read(entropy[:])
+	}
+	ulidState.lastMs = ms
+	ulidState.last = entropy
+
+	var id [16]byte
+	putMillis(id[:6], ms)
+	copy(id[6:], entropy[:])
+	return id
+}
+
+func encodeULID(id [16]byte) string {
+	// 128位按5位一组编码，首字符只占3位
+	out := make([]byte, 26)
+	var acc uint64
+	var bits uint
+	pos := 25
+	for i := len(id) - 1; i >= 0; i-- {
+		acc |= uint64(id[i]) << bits
+		bits += 8
+		for bits >= 5 && pos >= 0 {
+			out[pos] = crockford[acc&0x1f]
+			acc >>= 5
+			bits -= 5
+			pos--
+		}
+	}
+	if pos >= 0 {
+		out[pos] = crockford[acc&0x1f]
+	}
+	return string(out)
+}
+
+// NewUUIDv7 生成RFC 9562 UUIDv7，前48位为毫秒时间戳
+func NewUUIDv7() string {
+	var id [16]byte
+	_, _ = rand.Read(id[6:])
+	putMillis(id[:6], time.Now().UnixMilli())
+	id[6] = id[6]&0x0f | 0x70
+	id[8] = id[8]&0x3f | 0x80
+
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+	return string(buf)
+}
+
+func putMillis(b []byte, ms int64) {
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
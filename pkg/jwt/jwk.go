@@ -0,0 +1,110 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK RFC 7517 公钥
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS 公钥集合
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Find 按 kid 查找公钥
+func (s JWKS) Find(kid string) (JWK, bool) {
+	for _, k := range s.Keys {
+		if k.Kid == kid {
+			return k, true
+		}
+	}
+	return JWK{}, false
+}
+
+// NewJWK 将公钥转换为 JWK，支持 *rsa.PublicKey、*ecdsa.PublicKey(P-256) 及 ed25519.PublicKey
+func NewJWK(kid string, pub any) (JWK, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA", Kid: kid, Alg: RS256, Use: "sig",
+			N: encoding.EncodeToString(k.N.Bytes()),
+			E: encoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return JWK{}, errors.New("jwt: unsupported curve")
+		}
+		x, y := make([]byte, 32), make([]byte, 32)
+		k.X.FillBytes(x)
+		k.Y.FillBytes(y)
+		return JWK{
+			Kty: "EC", Kid: kid, Alg: ES256, Use: "sig", Crv: "P-256",
+			X: encoding.EncodeToString(x), Y: encoding.EncodeToString(y),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP", Kid: kid, Alg: EdDSA, Use: "sig", Crv: "Ed25519",
+			X: encoding.EncodeToString(k),
+		}, nil
+	}
+	return JWK{}, fmt.Errorf("jwt: unsupported key type %T", pub)
+}
+
+// PublicKey 解析为可用于 Parse 的公钥
+func (k JWK) PublicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := encoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := encoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, errors.New("jwt: unsupported curve")
+		}
+		x, err := encoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := encoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errors.New("jwt: unsupported curve")
+		}
+		x, err := encoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("jwt: invalid ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("jwt: unsupported key type %q", k.Kty)
+}
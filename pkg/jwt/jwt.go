@@ -0,0 +1,256 @@
+// Package jwt 提供 JWS 紧凑格式的签名与校验，支持 HS256、RS256、ES256 及 EdDSA
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed        = errors.New("jwt: malformed token")
+	ErrSignature        = errors.New("jwt: invalid signature")
+	ErrUnsupportedAlg   = errors.New("jwt: unsupported algorithm")
+	ErrExpired          = errors.New("jwt: token expired")
+	ErrNotYetValid      = errors.New("jwt: token not yet valid")
+	ErrInvalidIssuer    = errors.New("jwt: invalid issuer")
+	ErrInvalidAudience  = errors.New("jwt: invalid audience")
+	ErrInvalidKeyFormat = errors.New("jwt: key does not match algorithm")
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// Header JOSE 头部
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Audience 兼容字符串与字符串数组两种格式
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = Audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisteredClaims RFC 7519 中定义的声明，可嵌入自定义声明结构体
+type RegisteredClaims struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+}
+
+// Validate 校验有效期、签发者与受众，issuer 或 audience 为空时不校验
+func (c RegisteredClaims) Validate(now time.Time, leeway time.Duration, issuer, audience string) error {
+	if c.ExpiresAt != 0 && now.Add(-leeway).Unix() >= c.ExpiresAt {
+		return ErrExpired
+	}
+	if c.NotBefore != 0 && now.Add(leeway).Unix() < c.NotBefore {
+		return ErrNotYetValid
+	}
+	if issuer != "" && c.Issuer != issuer {
+		return ErrInvalidIssuer
+	}
+	if audience != "" && !c.Audience.Contains(audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+var encoding = base64.RawURLEncoding
+
+// Sign 签名并返回紧凑格式的令牌，key 为 []byte(HS256)、*rsa.PrivateKey、*ecdsa.PrivateKey 或 ed25519.PrivateKey
+func Sign(header Header, claims any, key any) (string, error) {
+	if header.Typ == "" {
+		header.Typ = "JWT"
+	}
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	p, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := encoding.EncodeToString(h) + "." + encoding.EncodeToString(p)
+	sig, err := sign(header.Alg, []byte(input), key)
+	if err != nil {
+		return "", err
+	}
+	return input + "." + encoding.EncodeToString(sig), nil
+}
+
+// Keyfunc 按头部返回校验密钥，返回的密钥类型需与算法匹配
+type Keyfunc func(Header) (any, error)
+
+// Parse 校验签名并将载荷解码到 claims，不校验声明内容
+func Parse(token string, keyfunc Keyfunc, claims any) (Header, error) {
+	var header Header
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return header, ErrMalformed
+	}
+	h, err := encoding.DecodeString(parts[0])
+	if err != nil {
+		return header, ErrMalformed
+	}
+	if err := json.Unmarshal(h, &header); err != nil {
+		return header, ErrMalformed
+	}
+	sig, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return header, ErrMalformed
+	}
+	key, err := keyfunc(header)
+	if err != nil {
+		return header, err
+	}
+	if err := verify(header.Alg, []byte(parts[0]+"."+parts[1]), sig, key); err != nil {
+		return header, err
+	}
+	p, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return header, ErrMalformed
+	}
+	if err := json.Unmarshal(p, claims); err != nil {
+		return header, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return header, nil
+}
+
+func sign(alg string, input []byte, key any) ([]byte, error) {
+	digest := sha256.Sum256(input)
+	switch alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return nil, ErrInvalidKeyFormat
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	case RS256:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKeyFormat
+		}
+		return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case ES256:
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKeyFormat
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	case EdDSA:
+		k, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKeyFormat
+		}
+		return ed25519.Sign(k, input), nil
+	}
+	return nil, ErrUnsupportedAlg
+}
+
+func verify(alg string, input, sig []byte, key any) error {
+	digest := sha256.Sum256(input)
+	switch alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrInvalidKeyFormat
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(input)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return ErrSignature
+		}
+		return nil
+	case RS256:
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrInvalidKeyFormat
+		}
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) != nil {
+			return ErrSignature
+		}
+		return nil
+	case ES256:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrInvalidKeyFormat
+		}
+		if len(sig) != 64 {
+			return ErrSignature
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return ErrSignature
+		}
+		return nil
+	case EdDSA:
+		k, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return ErrInvalidKeyFormat
+		}
+		if !ed25519.Verify(k, input, sig) {
+			return ErrSignature
+		}
+		return nil
+	}
+	return ErrUnsupportedAlg
+}
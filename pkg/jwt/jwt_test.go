@@ -0,0 +1,152 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testClaims struct {
+	RegisteredClaims
+	Name string `json:"name"`
+}
+
+func TestSignParse(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	cases := []struct {
+		alg  string
+		sign any
+		pub  any
+	}{
+		{HS256, []byte("secret"), []byte("secret")},
+		{RS256, rsaKey, &rsaKey.PublicKey},
+		{ES256, ecKey, &ecKey.PublicKey},
+		{EdDSA, edKey, edPub},
+	}
+	now := time.Now()
+	for _, tc := range cases {
+		claims := testClaims{
+			RegisteredClaims: RegisteredClaims{Issuer: "iss", Audience: Audience{"app"}, ExpiresAt: now.Add(time.Minute).Unix()},
+			Name:             "bob",
+		}
+		token, err := Sign(Header{Alg: tc.alg, Kid: "k1"}, claims, tc.sign)
+		if err != nil {
+			t.Fatalf("%s sign: %v", tc.alg, err)
+		}
+
+		var got testClaims
+		header, err := Parse(token, func(h Header) (any, error) { return tc.pub, nil }, &got)
+		if err != nil {
+			t.Fatalf("%s parse: %v", tc.alg, err)
+		}
+		if header.Kid != "k1" || got.Name != "bob" {
+			t.Fatalf("%s unexpected result %+v %+v", tc.alg, header, got)
+		}
+		if err := got.Validate(now, 0, "iss", "app"); err != nil {
+			t.Fatalf("%s validate: %v", tc.alg, err)
+		}
+
+		parts := strings.Split(token, ".")
+		tampered := parts[0] + "." + encoding.EncodeToString([]byte(`{"name":"eve"}`)) + "." + parts[2]
+		if _, err := Parse(tampered, func(h Header) (any, error) { return tc.pub, nil }, &got); !errors.Is(err, ErrSignature) {
+			t.Fatalf("%s tampered token: %v", tc.alg, err)
+		}
+	}
+}
+
+func TestParse_AlgorithmConfusion(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwk, _ := NewJWK("k1", &rsaKey.PublicKey)
+	// 使用公钥内容作为 HMAC 密钥伪造的令牌
+	token, _ := Sign(Header{Alg: HS256}, RegisteredClaims{Subject: "admin"}, []byte(jwk.N))
+	var claims RegisteredClaims
+	_, err := Parse(token, func(h Header) (any, error) { return jwk.PublicKey() }, &claims)
+	if !errors.Is(err, ErrInvalidKeyFormat) {
+		t.Fatalf("expected ErrInvalidKeyFormat, got %v", err)
+	}
+}
+
+func TestJWK_RoundTrip(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	for _, pub := range []any{&rsaKey.PublicKey, &ecKey.PublicKey, edPub} {
+		jwk, err := NewJWK("k", pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.(interface{ Equal(crypto.PublicKey) bool }).Equal(pub) {
+			t.Fatalf("%s key mismatch", jwk.Kty)
+		}
+	}
+}
+
+func TestRegisteredClaims_Validate(t *testing.T) {
+	now := time.Now()
+	c := RegisteredClaims{Issuer: "a", Audience: Audience{"x", "y"}, ExpiresAt: now.Unix() - 10}
+	if err := c.Validate(now, 0, "", ""); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected ErrExpired, got %v", err)
+	}
+	if err := c.Validate(now, time.Minute, "a", "y"); err != nil {
+		t.Fatalf("leeway should accept: %v", err)
+	}
+	if err := c.Validate(now, time.Minute, "b", ""); !errors.Is(err, ErrInvalidIssuer) {
+		t.Fatalf("expected ErrInvalidIssuer, got %v", err)
+	}
+	if err := c.Validate(now, time.Minute, "", "z"); !errors.Is(err, ErrInvalidAudience) {
+		t.Fatalf("expected ErrInvalidAudience, got %v", err)
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	_, k1, _ := ed25519.GenerateKey(rand.Reader)
+	_, k2, _ := ed25519.GenerateKey(rand.Reader)
+	der1, _ := x509.MarshalPKCS8PrivateKey(k1)
+	der2, _ := x509.MarshalPKCS8PrivateKey(k2)
+	spec := "k1:" + base64.StdEncoding.EncodeToString(der1) + "|k2:" + base64.StdEncoding.EncodeToString(der2)
+	keys, err := ParseKeys(EdDSA, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old, _ := NewKeyring(EdDSA, map[string]any{"k1": keys["k1"]}, "")
+	token, _ := old.Sign(RegisteredClaims{Subject: "1"})
+
+	ring, err := NewKeyring(EdDSA, keys, "k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims RegisteredClaims
+	if _, err := ring.Parse(token, &claims); err != nil || claims.Subject != "1" {
+		t.Fatalf("token signed by old key should verify: %v", err)
+	}
+	token, _ = ring.Sign(RegisteredClaims{Subject: "2"})
+	if h, err := ring.Parse(token, &claims); err != nil || h.Kid != "k2" {
+		t.Fatalf("unexpected %v %v", h, err)
+	}
+	if n := len(ring.JWKS().Keys); n != 2 {
+		t.Fatalf("expected 2 public keys, got %d", n)
+	}
+
+	hs, _ := NewKeyring(HS256, map[string]any{"h": []byte("secret")}, "")
+	forged, _ := hs.Sign(RegisteredClaims{Subject: "admin"})
+	if _, err := ring.Parse(forged, &claims); !errors.Is(err, ErrUnsupportedAlg) {
+		t.Fatalf("algorithm mismatch should be rejected, got %v", err)
+	}
+}
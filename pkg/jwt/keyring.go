@@ -0,0 +1,110 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Keyring 按 kid 管理的签名密钥，用于密钥轮换，校验时接受所有密钥
+type Keyring struct {
+	alg     string
+	current string
+	keys    map[string]any
+}
+
+// NewKeyring 创建密钥环，HS256 的密钥为 []byte，RS256 为 *rsa.PrivateKey，EdDSA 为 ed25519.PrivateKey
+func NewKeyring(alg string, keys map[string]any, current string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("jwt: empty keyring")
+	}
+	if current == "" && len(keys) == 1 {
+		for kid := range keys {
+			current = kid
+		}
+	}
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("jwt: current key %q not found", current)
+	}
+	for kid, key := range keys {
+		if _, err := sign(alg, nil, key); err != nil {
+			return nil, fmt.Errorf("jwt: key %q: %w", kid, err)
+		}
+	}
+	return &Keyring{alg: alg, current: current, keys: keys}, nil
+}
+
+// ParseKeys 解析 `kid:base64|kid:base64` 格式的密钥，HS256 为原始密钥，RS256 及 EdDSA 为 PKCS#8 DER
+func ParseKeys(alg, spec string) (map[string]any, error) {
+	keys := make(map[string]any)
+	for _, item := range strings.Split(spec, "|") {
+		kid, b64, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok || kid == "" {
+			return nil, fmt.Errorf("jwt: invalid key %q", item)
+		}
+		raw, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %q: %w", kid, err)
+		}
+		if alg == HS256 {
+			keys[kid] = raw
+			continue
+		}
+		key, err := x509.ParsePKCS8PrivateKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %q: %w", kid, err)
+		}
+		if k, ok := key.(*ed25519.PrivateKey); ok {
+			key = *k
+		}
+		keys[kid] = key
+	}
+	return keys, nil
+}
+
+// Sign 使用当前密钥签名
+func (k *Keyring) Sign(claims any) (string, error) {
+	return Sign(Header{Alg: k.alg, Kid: k.current}, claims, k.keys[k.current])
+}
+
+// Parse 校验签名并解码声明，算法必须与密钥环一致
+func (k *Keyring) Parse(token string, claims any) (Header, error) {
+	return Parse(token, func(h Header) (any, error) {
+		if h.Alg != k.alg {
+			return nil, ErrUnsupportedAlg
+		}
+		key, ok := k.keys[h.Kid]
+		if !ok {
+			return nil, fmt.Errorf("jwt: unknown key id %q", h.Kid)
+		}
+		if secret, ok := key.([]byte); ok {
+			return secret, nil
+		}
+		return key.(crypto.Signer).Public(), nil
+	}, claims)
+}
+
+// JWKS 公钥集合，HS256 时为空
+func (k *Keyring) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	if k.alg == HS256 {
+		return set
+	}
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	for _, kid := range kids {
+		pub := k.keys[kid].(crypto.Signer).Public()
+		if jwk, err := NewJWK(kid, pub); err == nil {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
@@ -0,0 +1,155 @@
+// Package loginguard 记录登录失败次数，按失败次数递增等待时间并临时锁定
+package loginguard
+
+import (
+	"context"
+	"time"
+)
+
+// Record 某个键（账号或 IP）的失败记录
+type Record struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+	// PrevFailure 最近一次 Attempt 之前的最近失败时间，Release 时恢复，避免撤销的尝试推迟统计窗口
+	PrevFailure time.Time
+}
+
+// Store 失败次数的存储，Attempt 需要保证判断与计数在并发下是原子的
+type Store interface {
+	Get(ctx context.Context, key string) (Record, error)
+	// Attempt allow 根据当前记录判断是否允许，允许时记录一次失败并返回新记录，否则不修改并返回当前记录
+	// 上次失败早于 now-window 时重新计数，lock 根据新记录返回锁定截止时间
+	Attempt(ctx context.Context, key string, now time.Time, window time.Duration, allow func(Record) bool, lock func(Record) time.Time) (Record, bool, error)
+	// Release 撤销一次 Attempt 的计数并恢复之前的最近失败时间，lock 根据新记录返回锁定截止时间
+	Release(ctx context.Context, key string, lock func(Record) time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy 失败策略，各时长为 0 时不启用对应限制
+type Policy struct {
+	// Window 失败次数的统计窗口，超过该时间未失败时重新计数
+	Window time.Duration
+	// BaseDelay 首次失败后的等待时间，之后每次失败翻倍，不超过 MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxFailures 连续失败达到该次数时锁定 LockDuration
+	MaxFailures  int
+	LockDuration time.Duration
+	// CaptchaAfter 连续失败达到该次数后要求验证码
+	CaptchaAfter int
+}
+
+// Decision 是否允许本次登录尝试
+type Decision struct {
+	Allowed    bool
+	Locked     bool
+	RetryAfter time.Duration
+	Captcha    bool
+	Failures   int
+}
+
+// Guard 按策略判断登录尝试
+type Guard struct {
+	store  Store
+	policy Policy
+	now    func() time.Time
+}
+
+func New(store Store, policy Policy) *Guard {
+	return &Guard{store: store, policy: policy, now: time.Now}
+}
+
+// Check 判断 key 当前是否允许尝试登录，不修改计数
+func (g *Guard) Check(ctx context.Context, key string) (Decision, error) {
+	record, err := g.store.Get(ctx, key)
+	if err != nil {
+		return Decision{}, err
+	}
+	return g.decide(record, g.now()), nil
+}
+
+// Attempt 判断是否允许本次尝试，允许时预先记为一次失败，并发的尝试不会同时通过判断
+// 返回的判断基于尝试之前的记录，尝试成功后应调用 Release 或 Reset 撤销
+func (g *Guard) Attempt(ctx context.Context, key string) (Decision, error) {
+	now := g.now()
+	var d Decision
+	_, _, err := g.store.Attempt(ctx, key, now, g.policy.Window, func(r Record) bool {
+		d = g.decide(r, now)
+		return d.Allowed
+	}, g.lock(now))
+	if err != nil {
+		return Decision{}, err
+	}
+	return d, nil
+}
+
+// Fail 记录一次失败，返回下一次尝试的判断
+func (g *Guard) Fail(ctx context.Context, key string) (Decision, error) {
+	now := g.now()
+	record, _, err := g.store.Attempt(ctx, key, now, g.policy.Window, func(Record) bool { return true }, g.lock(now))
+	if err != nil {
+		return Decision{}, err
+	}
+	return g.decide(record, now), nil
+}
+
+// Release 撤销一次 Attempt 的计数，计数低于上限时解除该次尝试造成的锁定
+func (g *Guard) Release(ctx context.Context, key string) error {
+	now := g.now()
+	return g.store.Release(ctx, key, func(r Record) time.Time {
+		if g.policy.MaxFailures > 0 && r.Failures >= g.policy.MaxFailures || r.LockedUntil.Before(now) {
+			return r.LockedUntil
+		}
+		return now
+	})
+}
+
+// lock 失败次数达到上限时返回新的锁定截止时间
+func (g *Guard) lock(now time.Time) func(Record) time.Time {
+	return func(r Record) time.Time {
+		if g.policy.MaxFailures > 0 && r.Failures >= g.policy.MaxFailures && g.policy.LockDuration > 0 {
+			return now.Add(g.policy.LockDuration)
+		}
+		return r.LockedUntil
+	}
+}
+
+// Reset 登录成功或管理员解锁时清除记录
+func (g *Guard) Reset(ctx context.Context, key string) error {
+	return g.store.Reset(ctx, key)
+}
+
+func (g *Guard) decide(r Record, now time.Time) Decision {
+	if g.policy.Window > 0 && now.Sub(r.LastFailure) >= g.policy.Window && !now.Before(r.LockedUntil) {
+		r.Failures = 0
+	}
+	d := Decision{Allowed: true, Failures: r.Failures}
+	d.Captcha = g.policy.CaptchaAfter > 0 && r.Failures >= g.policy.CaptchaAfter
+	if now.Before(r.LockedUntil) {
+		d.Allowed, d.Locked, d.RetryAfter = false, true, r.LockedUntil.Sub(now)
+		return d
+	}
+	if wait := r.LastFailure.Add(g.delay(r.Failures)); now.Before(wait) {
+		d.Allowed, d.RetryAfter = false, wait.Sub(now)
+	}
+	return d
+}
+
+// delay 第 failures 次失败后的等待时间
+func (g *Guard) delay(failures int) time.Duration {
+	if failures <= 0 || g.policy.BaseDelay <= 0 {
+		return 0
+	}
+	d := g.policy.BaseDelay
+	for i := 1; i < failures; i++ {
+		d *= 2
+		if g.policy.MaxDelay > 0 && d >= g.policy.MaxDelay {
+			return g.policy.MaxDelay
+		}
+	}
+	if g.policy.MaxDelay > 0 && d > g.policy.MaxDelay {
+		return g.policy.MaxDelay
+	}
+	return d
+}
@@ -0,0 +1,151 @@
+package loginguard
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGuard_DelayAndLock(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	g := New(NewMemoryStore(), Policy{
+		Window:       15 * time.Minute,
+		BaseDelay:    time.Second,
+		MaxDelay:     4 * time.Second,
+		MaxFailures:  5,
+		LockDuration: 15 * time.Minute,
+		CaptchaAfter: 3,
+	})
+	g.now = func() time.Time { return now }
+
+	// 等待时间依次为 1s 2s 4s 4s，第 5 次失败后锁定
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		d, err := g.Fail(ctx, "account:bob")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Allowed || d.Locked || d.RetryAfter != want {
+			t.Fatalf("failure %d: unexpected decision %+v", i+1, d)
+		}
+		if d.Captcha != (i+1 >= 3) {
+			t.Fatalf("failure %d: captcha should be %v", i+1, i+1 >= 3)
+		}
+		now = now.Add(want)
+		if d, _ := g.Check(ctx, "account:bob"); !d.Allowed {
+			t.Fatalf("failure %d: should be allowed after delay: %+v", i+1, d)
+		}
+	}
+	d, _ := g.Fail(ctx, "account:bob")
+	if !d.Locked || d.RetryAfter != 15*time.Minute {
+		t.Fatalf("should be locked: %+v", d)
+	}
+	if d, _ := g.Check(ctx, "account:alice"); !d.Allowed || d.Failures != 0 {
+		t.Fatalf("other keys should not be affected: %+v", d)
+	}
+
+	now = now.Add(15 * time.Minute)
+	if d, _ := g.Check(ctx, "account:bob"); !d.Allowed {
+		t.Fatalf("lock should expire: %+v", d)
+	}
+	if d, _ := g.Fail(ctx, "account:bob"); d.Failures != 1 {
+		t.Fatalf("failures should restart after the window: %+v", d)
+	}
+
+	if err := g.Reset(ctx, "account:bob"); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := g.Check(ctx, "account:bob"); !d.Allowed || d.Failures != 0 {
+		t.Fatalf("reset should clear the record: %+v", d)
+	}
+}
+
+func TestGuard_AttemptIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name    string
+		policy  Policy
+		allowed int
+	}{
+		// 没有等待时间时，并发尝试最多通过 MaxFailures 次
+		{"lock", Policy{Window: time.Minute, MaxFailures: 5, LockDuration: time.Minute}, 5},
+		// 有等待时间时，同一时刻只有一次尝试通过
+		{"delay", Policy{Window: time.Minute, BaseDelay: time.Second, MaxFailures: 5, LockDuration: time.Minute}, 1},
+	}
+	for _, tc := range cases {
+		g := New(NewMemoryStore(), tc.policy)
+		var wg sync.WaitGroup
+		var allowed atomic.Int32
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d, err := g.Attempt(ctx, "account:bob")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if d.Allowed {
+					allowed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if int(allowed.Load()) != tc.allowed {
+			t.Errorf("%s: %d attempts allowed, want %d", tc.name, allowed.Load(), tc.allowed)
+		}
+	}
+}
+
+func TestGuard_Release(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	g := New(NewMemoryStore(), Policy{Window: time.Minute, MaxFailures: 2, LockDuration: time.Minute})
+	g.now = func() time.Time { return now }
+
+	if _, err := g.Fail(ctx, "ip:1.2.3.4"); err != nil {
+		t.Fatal(err)
+	}
+	// 达到上限的这次尝试成功时撤销计数并解除锁定
+	if d, _ := g.Attempt(ctx, "ip:1.2.3.4"); !d.Allowed {
+		t.Fatalf("second attempt should be allowed: %+v", d)
+	}
+	if d, _ := g.Check(ctx, "ip:1.2.3.4"); !d.Locked {
+		t.Fatalf("pending attempt should count towards the lock: %+v", d)
+	}
+	if err := g.Release(ctx, "ip:1.2.3.4"); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := g.Check(ctx, "ip:1.2.3.4"); !d.Allowed || d.Failures != 1 {
+		t.Fatalf("release should undo the attempt: %+v", d)
+	}
+}
+
+// 成功的尝试撤销后不推迟统计窗口，之前的失败按原时间过期
+func TestGuard_ReleaseKeepsWindow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	g := New(NewMemoryStore(), Policy{Window: time.Minute, MaxFailures: 2, LockDuration: time.Minute})
+	g.now = func() time.Time { return now }
+
+	if _, err := g.Fail(ctx, "ip:1.2.3.4"); err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		now = now.Add(20 * time.Second)
+		if d, _ := g.Attempt(ctx, "ip:1.2.3.4"); !d.Allowed {
+			t.Fatalf("attempt should be allowed: %+v", d)
+		}
+		if err := g.Release(ctx, "ip:1.2.3.4"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d, _ := g.Check(ctx, "ip:1.2.3.4"); d.Failures != 0 {
+		t.Fatalf("old failure should expire after the window: %+v", d)
+	}
+	if d, _ := g.Fail(ctx, "ip:1.2.3.4"); d.Locked || d.Failures != 1 {
+		t.Fatalf("a new failure should start a new window: %+v", d)
+	}
+}
@@ -0,0 +1,201 @@
+package loginguard
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MemoryStore 进程内存储，仅适用于单实例部署及测试
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+	calls   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records[key], nil
+}
+
+func (s *MemoryStore) Attempt(_ context.Context, key string, now time.Time, window time.Duration, allow func(Record) bool, lock func(Record) time.Time) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls%1024 == 0 {
+		s.cleanup(now, window)
+	}
+
+	r := s.records[key]
+	if !allow(r) {
+		return r, false, nil
+	}
+	r = next(r, now, window)
+	r.LockedUntil = lock(r)
+	s.records[key] = r
+	return r, true, nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string, lock func(Record) time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[key]
+	if !ok {
+		return nil
+	}
+	r.Failures = max(r.Failures-1, 0)
+	r.LastFailure = r.PrevFailure
+	r.LockedUntil = lock(r)
+	s.records[key] = r
+	return nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// cleanup 删除已过统计窗口且未锁定的记录，调用方需持有锁
+func (s *MemoryStore) cleanup(now time.Time, window time.Duration) {
+	for key, r := range s.records {
+		if now.Sub(r.LastFailure) >= window && !now.Before(r.LockedUntil) {
+			delete(s.records, key)
+		}
+	}
+}
+
+// next 在窗口内累加失败次数，否则重新计数
+func next(r Record, now time.Time, window time.Duration) Record {
+	if window > 0 && now.Sub(r.LastFailure) >= window && !now.Before(r.LockedUntil) {
+		r.Failures = 0
+	}
+	r.Failures++
+	r.PrevFailure = r.LastFailure
+	r.LastFailure = now
+	return r
+}
+
+// FailureRecord 数据库中的失败记录
+type FailureRecord struct {
+	Key         string    `gorm:"primaryKey;type:VARCHAR(191);NOT NULL;comment:账号或IP"`
+	Failures    int       `gorm:"NOT NULL;default:0;comment:连续失败次数"`
+	LastFailure time.Time `gorm:"type:DATETIME(3);NOT NULL;index;comment:最近失败时间"`
+	LockedUntil time.Time `gorm:"type:DATETIME(3);NOT NULL;comment:锁定截止时间"`
+	// PrevFailure 最近一次尝试之前的最近失败时间，撤销该次尝试时恢复
+	PrevFailure *time.Time `gorm:"type:DATETIME(3);NULL;comment:尝试前的最近失败时间"`
+}
+
+func (r FailureRecord) record() Record {
+	record := Record{Failures: r.Failures, LastFailure: r.LastFailure, LockedUntil: r.LockedUntil}
+	if r.PrevFailure != nil {
+		record.PrevFailure = *r.PrevFailure
+	}
+	return record
+}
+
+func (FailureRecord) TableName() string {
+	return "login_failure"
+}
+
+// GormStore 使用数据库存储，多实例部署时共享计数
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// Migrate 创建失败记录表
+func (s *GormStore) Migrate() error {
+	return s.db.AutoMigrate(&FailureRecord{})
+}
+
+func (s *GormStore) Get(ctx context.Context, key string) (Record, error) {
+	var record FailureRecord
+	err := s.db.WithContext(ctx).Take(&record, "`key` = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Record{}, nil
+	}
+	if err != nil {
+		return Record{}, err
+	}
+	return record.record(), nil
+}
+
+func (s *GormStore) Attempt(ctx context.Context, key string, now time.Time, window time.Duration, allow func(Record) bool, lock func(Record) time.Time) (Record, bool, error) {
+	var r Record
+	allowed := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先确保记录存在，再加锁读取，判断与计数在同一事务中完成，并发的尝试依次执行
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&FailureRecord{Key: key, LastFailure: now, LockedUntil: now}).Error; err != nil {
+			return err
+		}
+		var record FailureRecord
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&record, "`key` = ?", key).Error; err != nil {
+			return err
+		}
+		r = record.record()
+		if !allow(r) {
+			return nil
+		}
+		allowed = true
+		r = next(r, now, window)
+		r.LockedUntil = lock(r)
+		return tx.Model(&record).Updates(map[string]any{
+			"failures":     r.Failures,
+			"last_failure": r.LastFailure,
+			"locked_until": r.LockedUntil,
+			"prev_failure": r.PrevFailure,
+		}).Error
+	})
+	return r, allowed, err
+}
+
+func (s *GormStore) Release(ctx context.Context, key string, lock func(Record) time.Time) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var record FailureRecord
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&record, "`key` = ?", key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		r := record.record()
+		r.Failures = max(r.Failures-1, 0)
+		// 新增该列之前写入的记录没有尝试前的时间，保持不变
+		if record.PrevFailure != nil {
+			r.LastFailure = r.PrevFailure
+		}
+		r.LockedUntil = lock(r)
+		return tx.Model(&record).Updates(map[string]any{
+			"failures":     r.Failures,
+			"last_failure": r.LastFailure,
+			"locked_until": r.LockedUntil,
+		}).Error
+	})
+}
+
+func (s *GormStore) Reset(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Delete(&FailureRecord{}, "`key` = ?", key).Error
+}
+
+// GC 删除 before 之前最后失败且未锁定的记录
+func (s *GormStore) GC(ctx context.Context, before time.Time) error {
+	return s.db.WithContext(ctx).
+		Where("last_failure < ? AND locked_until < ?", before, time.Now()).
+		Delete(&FailureRecord{}).Error
+}
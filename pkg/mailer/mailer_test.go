@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	msg := Message{To: []string{"bob@example.com"}, Subject: "重置密码", Body: "line1\nline2"}
+	data, err := Encode("tz-gin <no-reply@example.com>", msg, time.Unix(1_700_000_000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "重置密码" {
+		t.Fatalf("unexpected subject %q %v", parsed.Header.Get("Subject"), err)
+	}
+	if !strings.Contains(string(data), "line1\r\nline2") {
+		t.Fatalf("body should use CRLF line endings: %q", data)
+	}
+
+	if _, err := Encode("no-reply@example.com", Message{To: []string{"bob@example.com\r\nBcc: eve@example.com"}}, time.Now()); err == nil {
+		t.Fatal("header injection should be rejected")
+	}
+	if _, err := Encode("no-reply@example.com", Message{}, time.Now()); !errors.Is(err, ErrNoRecipient) {
+		t.Fatalf("expected ErrNoRecipient, got %v", err)
+	}
+}
+
+func TestFileAndMemory(t *testing.T) {
+	ctx := context.Background()
+	msg := Message{To: []string{"bob@example.com"}, Subject: "hello", Body: "hi"}
+
+	dir := t.TempDir()
+	f := &File{Dir: filepath.Join(dir, "mail"), From: "no-reply@example.com"}
+	if err := f.Send(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := os.ReadDir(f.Dir)
+	if len(files) != 1 || filepath.Ext(files[0].Name()) != ".eml" {
+		t.Fatalf("expected one .eml file, got %v", files)
+	}
+
+	m := &Memory{}
+	if err := m.Send(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+	if last, ok := m.Last(); !ok || last.Subject != "hello" || len(m.Messages()) != 1 {
+		t.Fatalf("unexpected messages %+v", m.Messages())
+	}
+}
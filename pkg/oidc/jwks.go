@@ -0,0 +1,74 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"template/pkg/jwt"
+)
+
+const (
+	// jwksTTL 公钥集合的缓存时间
+	jwksTTL = time.Hour
+	// jwksMinRefresh 遇到未知 kid 时两次刷新的最小间隔，避免伪造的 kid 导致频繁请求
+	jwksMinRefresh = time.Minute
+)
+
+// keySet 缓存身份提供方的公钥集合，密钥轮换时按 kid 自动刷新
+type keySet struct {
+	client *http.Client
+	uri    string
+
+	mu        sync.Mutex
+	keys      jwt.JWKS
+	fetchedAt time.Time
+	now       func() time.Time
+}
+
+func newKeySet(client *http.Client, uri string) *keySet {
+	return &keySet{client: client, uri: uri, now: time.Now}
+}
+
+func (s *keySet) key(ctx context.Context, kid string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.fetchedAt.IsZero() || now.Sub(s.fetchedAt) > jwksTTL {
+		if err := s.refresh(ctx, now); err != nil {
+			return nil, err
+		}
+	}
+	k, ok := s.find(kid)
+	if !ok && now.Sub(s.fetchedAt) > jwksMinRefresh {
+		if err := s.refresh(ctx, now); err != nil {
+			return nil, err
+		}
+		k, ok = s.find(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("oidc: unknown key id %q", kid)
+	}
+	return k.PublicKey()
+}
+
+func (s *keySet) find(kid string) (jwt.JWK, bool) {
+	// 只有一个公钥时允许令牌不带 kid
+	if kid == "" && len(s.keys.Keys) == 1 {
+		return s.keys.Keys[0], true
+	}
+	return s.keys.Find(kid)
+}
+
+func (s *keySet) refresh(ctx context.Context, now time.Time) error {
+	var keys jwt.JWKS
+	if err := getJSON(ctx, s.client, s.uri, &keys); err != nil {
+		return err
+	}
+	s.keys = keys
+	s.fetchedAt = now
+	return nil
+}
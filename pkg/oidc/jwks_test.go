@@ -0,0 +1,37 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"template/pkg/oidc/oidctest"
+)
+
+func TestKeySet_RefreshOnUnknownKid(t *testing.T) {
+	idp, err := oidctest.NewServer("client", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idp.Close()
+	ks := newKeySet(http.DefaultClient, idp.Issuer()+"/jwks")
+	now := time.Now()
+	ks.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := ks.key(ctx, "key-1"); err != nil {
+		t.Fatal(err)
+	}
+	_ = idp.RotateKey()
+	now = now.Add(2 * jwksMinRefresh)
+	if _, err := ks.key(ctx, "key-2"); err != nil {
+		t.Fatalf("rotated key should be fetched: %v", err)
+	}
+	if _, err := ks.key(ctx, "key-1"); err != nil {
+		t.Fatalf("old key should remain valid: %v", err)
+	}
+	if n := idp.JWKSRequests(); n != 2 {
+		t.Fatalf("expected 2 jwks requests, got %d", n)
+	}
+}
@@ -0,0 +1,139 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"template/pkg/jwt"
+	"template/pkg/oidc"
+	"template/pkg/oidc/oidctest"
+)
+
+func setup(t *testing.T) (*oidctest.Server, *oidc.Provider) {
+	t.Helper()
+	idp, err := oidctest.NewServer("client", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+	p, err := oidc.Discover(context.Background(), oidc.Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://app.local/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idp, p
+}
+
+// authorize 访问授权地址并返回回调中的参数
+func authorize(t *testing.T, authURL string) url.Values {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned %d", resp.StatusCode)
+	}
+	loc, _ := url.Parse(resp.Header.Get("Location"))
+	return loc.Query()
+}
+
+func TestFlow(t *testing.T) {
+	_, p := setup(t)
+	ctx := context.Background()
+	state, nonce, verifier := oidc.RandomString(), oidc.RandomString(), oidc.RandomString()
+
+	cb := authorize(t, p.AuthCodeURL(state, nonce, verifier))
+	if cb.Get("state") != state {
+		t.Fatalf("state mismatch")
+	}
+	if _, err := p.Exchange(ctx, cb.Get("code"), "wrong-verifier"); err == nil {
+		t.Fatal("exchange with wrong verifier should fail")
+	}
+
+	cb = authorize(t, p.AuthCodeURL(state, nonce, verifier))
+	token, err := p.Exchange(ctx, cb.Get("code"), verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Exchange(ctx, cb.Get("code"), verifier); err == nil {
+		t.Fatal("code should be single use")
+	}
+
+	id, err := p.Verify(ctx, token.IDToken, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Subject != "10001" || id.Email != "bob@example.com" {
+		t.Fatalf("unexpected claims %+v", id)
+	}
+	if _, err := p.Verify(ctx, token.IDToken, "other"); !errors.Is(err, oidc.ErrNonce) {
+		t.Fatalf("expected ErrNonce, got %v", err)
+	}
+}
+
+func TestVerify_InvalidClaims(t *testing.T) {
+	idp, p := setup(t)
+	ctx := context.Background()
+	now := time.Now()
+	base := func() map[string]any {
+		return map[string]any{"iss": idp.Issuer(), "sub": "1", "aud": "client", "exp": now.Add(time.Hour).Unix(), "nonce": "n"}
+	}
+
+	cases := map[string]func(map[string]any){
+		"audience": func(c map[string]any) { c["aud"] = "other" },
+		"issuer":   func(c map[string]any) { c["iss"] = "http://evil" },
+		"expired":  func(c map[string]any) { c["exp"] = now.Add(-time.Hour).Unix() },
+	}
+	for name, mutate := range cases {
+		claims := base()
+		mutate(claims)
+		raw, _ := idp.SignIDToken(claims)
+		if _, err := p.Verify(ctx, raw, "n"); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	forged, _ := jwt.Sign(jwt.Header{Alg: jwt.HS256}, base(), []byte("secret"))
+	if _, err := p.Verify(ctx, forged, "n"); err == nil {
+		t.Error("HS256 token should be rejected")
+	}
+}
+
+func TestVerify_KeyRotation(t *testing.T) {
+	idp, p := setup(t)
+	ctx := context.Background()
+	claims := map[string]any{"iss": idp.Issuer(), "sub": "1", "aud": "client", "exp": time.Now().Add(time.Hour).Unix()}
+
+	raw, _ := idp.SignIDToken(claims)
+	for i := 0; i < 3; i++ {
+		if _, err := p.Verify(ctx, raw, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := idp.JWKSRequests(); n != 1 {
+		t.Fatalf("jwks should be cached, fetched %d times", n)
+	}
+
+	// 刚刷新过的公钥集合不会因未知 kid 立即重新请求
+	_ = idp.RotateKey()
+	raw, _ = idp.SignIDToken(claims)
+	if _, err := p.Verify(ctx, raw, ""); err == nil {
+		t.Fatal("unknown kid should fail within refresh interval")
+	}
+	if n := idp.JWKSRequests(); n != 1 {
+		t.Fatalf("jwks refreshed too often: %d", n)
+	}
+}
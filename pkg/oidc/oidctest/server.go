@@ -0,0 +1,245 @@
+// Package oidctest 提供进程内的 OpenID Connect 身份提供方，用于测试，授权请求会自动通过
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"template/pkg/jwt"
+)
+
+// User 授权时返回的用户信息
+type User struct {
+	Subject           string
+	Email             string
+	Name              string
+	PreferredUsername string
+}
+
+type authorization struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+	user        User
+}
+
+// Server 进程内身份提供方
+type Server struct {
+	ClientID     string
+	ClientSecret string
+	// User 下一次授权返回的用户
+	User User
+
+	server *httptest.Server
+
+	mu           sync.Mutex
+	kid          string
+	key          *rsa.PrivateKey
+	oldKeys      []jwt.JWK
+	codes        map[string]authorization
+	jwksRequests int
+}
+
+// NewServer 启动身份提供方
+func NewServer(clientID, clientSecret string) (*Server, error) {
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		User:         User{Subject: "10001", Email: "bob@example.com", Name: "Bob", PreferredUsername: "bob"},
+		codes:        make(map[string]authorization),
+	}
+	if err := s.RotateKey(); err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/jwks", s.jwks)
+	s.server = httptest.NewServer(mux)
+	return s, nil
+}
+
+// Issuer 签发者地址
+func (s *Server) Issuer() string {
+	return s.server.URL
+}
+
+// Close 停止服务
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// RotateKey 更换签名密钥，旧公钥仍保留在公钥集合中
+func (s *Server) RotateKey() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key != nil {
+		old, _ := jwt.NewJWK(s.kid, &s.key.PublicKey)
+		s.oldKeys = append(s.oldKeys, old)
+	}
+	s.kid = fmt.Sprintf("key-%d", len(s.oldKeys)+1)
+	s.key = key
+	return nil
+}
+
+// JWKSRequests 公钥集合被请求的次数
+func (s *Server) JWKSRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jwksRequests
+}
+
+// SignIDToken 使用当前密钥签发任意声明的令牌，用于构造异常令牌
+func (s *Server) SignIDToken(claims any) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return jwt.Sign(jwt.Header{Alg: jwt.RS256, Kid: s.kid}, claims, s.key)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func oauthError(w http.ResponseWriter, code, desc string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code, "error_description": desc})
+}
+
+func (s *Server) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.Issuer(),
+		"authorization_endpoint": s.Issuer() + "/authorize",
+		"token_endpoint":         s.Issuer() + "/token",
+		"jwks_uri":               s.Issuer() + "/jwks",
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jwksRequests++
+	current, _ := jwt.NewJWK(s.kid, &s.key.PublicKey)
+	writeJSON(w, http.StatusOK, jwt.JWKS{Keys: append([]jwt.JWK{current}, s.oldKeys...)})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch {
+	case q.Get("client_id") != s.ClientID:
+		oauthError(w, "invalid_client", "unknown client_id")
+		return
+	case q.Get("response_type") != "code":
+		oauthError(w, "unsupported_response_type", "only code is supported")
+		return
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		oauthError(w, "invalid_request", "PKCE S256 is required")
+		return
+	}
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirect.Scheme == "" {
+		oauthError(w, "invalid_request", "invalid redirect_uri")
+		return
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = authorization{
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		redirectURI: q.Get("redirect_uri"),
+		user:        s.User,
+	}
+	s.mu.Unlock()
+
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", q.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		oauthError(w, "invalid_request", err.Error())
+		return
+	}
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id != s.ClientID || secret != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		oauthError(w, "unsupported_grant_type", "")
+		return
+	}
+
+	s.mu.Lock()
+	code := r.PostForm.Get("code")
+	auth, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+	if !ok || auth.redirectURI != r.PostForm.Get("redirect_uri") {
+		oauthError(w, "invalid_grant", "invalid code")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		oauthError(w, "invalid_grant", "code_verifier mismatch")
+		return
+	}
+
+	now := time.Now()
+	idToken, err := s.SignIDToken(map[string]any{
+		"iss":                s.Issuer(),
+		"sub":                auth.user.Subject,
+		"aud":                s.ClientID,
+		"exp":                now.Add(time.Hour).Unix(),
+		"iat":                now.Unix(),
+		"nonce":              auth.nonce,
+		"email":              auth.user.Email,
+		"email_verified":     auth.user.Email != "",
+		"name":               auth.user.Name,
+		"preferred_username": auth.user.PreferredUsername,
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,41 @@
+package password
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashVerify(t *testing.T) {
+	hash, err := Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, rehash, err := Verify(hash, "correct horse"); !ok || rehash || err != nil {
+		t.Fatalf("verify = %v %v %v", ok, rehash, err)
+	}
+	if ok, _, err := Verify(hash, "wrong"); ok || err != nil {
+		t.Fatalf("wrong password verified: %v %v", ok, err)
+	}
+	if _, _, err := Verify("plain", "plain"); err != ErrInvalidHash {
+		t.Fatalf("expected ErrInvalidHash, got %v", err)
+	}
+}
+
+func TestVerify_Rehash(t *testing.T) {
+	old := DefaultParams
+	DefaultParams.Iterations = 1
+	hash, _ := Hash("secret")
+	DefaultParams = old
+	if ok, rehash, _ := Verify(hash, "secret"); !ok || !rehash {
+		t.Fatalf("outdated params should need rehash: %v %v", ok, rehash)
+	}
+
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if ok, rehash, _ := Verify(string(legacy), "secret"); !ok || !rehash {
+		t.Fatalf("bcrypt hash should verify and need rehash: %v %v", ok, rehash)
+	}
+	if ok, _, _ := Verify(string(legacy), "nope"); ok {
+		t.Fatal("bcrypt wrong password verified")
+	}
+}
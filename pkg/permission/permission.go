@@ -0,0 +1,66 @@
+// Package permission 提供以冒号分层的权限字符串匹配，如 resource:delete
+// 授予的权限中 * 匹配任意一段，位于末尾时匹配其后的所有层级，如 resource:* 包含 resource:comment:delete
+package permission
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalid 权限字符串格式错误
+var ErrInvalid = errors.New("permission: invalid format")
+
+// Validate 校验权限字符串，各段不能为空，只能包含字母、数字、-、_ 或单独的 *
+func Validate(p string) error {
+	if p == "" || len(p) > 128 {
+		return ErrInvalid
+	}
+	for _, part := range strings.Split(p, ":") {
+		if part == "*" {
+			continue
+		}
+		if part == "" {
+			return ErrInvalid
+		}
+		for _, r := range part {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return ErrInvalid
+			}
+		}
+	}
+	return nil
+}
+
+// Match 授予的权限 granted 是否包含 required
+func Match(granted, required string) bool {
+	g := strings.Split(granted, ":")
+	r := strings.Split(required, ":")
+	for i, part := range g {
+		if i >= len(r) {
+			return false
+		}
+		if part == "*" {
+			if i == len(g)-1 {
+				return true
+			}
+			continue
+		}
+		if part != r[i] {
+			return false
+		}
+	}
+	return len(g) == len(r)
+}
+
+// Set 用户拥有的权限集合
+type Set []string
+
+// Has 集合中是否有权限包含 required
+func (s Set) Has(required string) bool {
+	for _, granted := range s {
+		if Match(granted, required) {
+			return true
+		}
+	}
+	return false
+}
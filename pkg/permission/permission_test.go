@@ -0,0 +1,41 @@
+package permission
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		granted, required string
+		want              bool
+	}{
+		{"resource:delete", "resource:delete", true},
+		{"resource:delete", "resource:edit", false},
+		{"resource:*", "resource:delete", true},
+		{"resource:*", "resource:comment:delete", true},
+		{"resource:*", "resource", false},
+		{"*", "user:delete", true},
+		{"*:read", "resource:read", true},
+		{"*:read", "resource:write", false},
+		{"resource", "resource:delete", false},
+		{"resource:comment:delete", "resource:comment", false},
+	}
+	for _, tc := range cases {
+		if got := Match(tc.granted, tc.required); got != tc.want {
+			t.Errorf("Match(%q, %q) = %v", tc.granted, tc.required, got)
+		}
+	}
+
+	s := Set{"resource:read", "user:*"}
+	if !s.Has("user:delete") || s.Has("resource:delete") {
+		t.Fatal("unexpected set result")
+	}
+	for _, p := range []string{"resource:delete", "*", "user:*:read"} {
+		if Validate(p) != nil {
+			t.Errorf("%q should be valid", p)
+		}
+	}
+	for _, p := range []string{"", "resource::delete", "resource:de lete", "res*:read"} {
+		if Validate(p) == nil {
+			t.Errorf("%q should be invalid", p)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package policy
+
+import "context"
+
+type envKey struct{}
+
+// ContextWithEnv 将环境属性（IP、请求ID等）写入 context，供服务中的授权请求使用
+func ContextWithEnv(ctx context.Context, env map[string]any) context.Context {
+	return context.WithValue(ctx, envKey{}, env)
+}
+
+// EnvFrom 读取 context 中的环境属性，未设置时返回 nil
+func EnvFrom(ctx context.Context) map[string]any {
+	if ctx == nil {
+		return nil
+	}
+	env, _ := ctx.Value(envKey{}).(map[string]any)
+	return env
+}
@@ -0,0 +1,173 @@
+// Package policy 基于属性的访问控制，按资源类型及操作注册策略，根据主体、资源及环境属性作出决定
+// 任一策略拒绝时拒绝，否则任一策略允许时允许，没有策略允许时默认拒绝
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"template/pkg/permission"
+)
+
+// Effect 单条策略的结果
+type Effect int
+
+const (
+	Abstain Effect = iota // 不适用
+	Allow
+	Deny
+)
+
+func (e Effect) String() string {
+	switch e {
+	case Abstain:
+		return "abstain"
+	case Allow:
+		return "allow"
+	case Deny:
+		return "deny"
+	}
+	return fmt.Sprintf("Effect(%d)", int(e))
+}
+
+func (e Effect) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// Subject 发起操作的主体，ID 为 0 表示未登录
+type Subject struct {
+	ID          int            `json:"id"`
+	Level       int            `json:"level"`
+	Permissions permission.Set `json:"permissions"`
+	Attrs       map[string]any `json:"attrs,omitempty"`
+}
+
+// Request 一次授权请求，Resource 为 nil 时表示对资源类型整体的操作，如创建
+type Request struct {
+	Subject  Subject
+	Type     string
+	Action   string
+	Resource any
+	Env      map[string]any
+}
+
+// Rule 策略的判断逻辑，reason 记录在决定日志中说明原因
+type Rule func(ctx context.Context, req Request) (effect Effect, reason string)
+
+// Result 单条策略的执行结果
+type Result struct {
+	Policy string `json:"policy"`
+	Effect Effect `json:"effect"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Decision 授权决定，Results 按注册顺序记录每条适用策略的结果
+type Decision struct {
+	Allowed   bool      `json:"allowed"`
+	SubjectID int       `json:"subjectId"`
+	Type      string    `json:"type"`
+	Action    string    `json:"action"`
+	Reason    string    `json:"reason"`
+	Results   []Result  `json:"results"`
+	RequestID string    `json:"requestId,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+type policy struct {
+	name string
+	rule Rule
+}
+
+// Engine 策略引擎，注册应在启动时完成，Evaluate 可并发调用
+type Engine struct {
+	mu       sync.RWMutex
+	policies map[string][]policy
+
+	// Log 每次作出决定后调用，为 nil 时不记录
+	Log func(ctx context.Context, d Decision)
+}
+
+func New() *Engine {
+	return &Engine{policies: make(map[string][]policy)}
+}
+
+// Register 为资源类型 typ 的 action 操作注册策略，action 为 * 时适用于该类型的所有操作
+func (e *Engine) Register(typ, action, name string, rule Rule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	key := typ + "#" + action
+	e.policies[key] = append(e.policies[key], policy{name: name, rule: rule})
+}
+
+// Evaluate 执行适用于 req 的全部策略并作出决定
+func (e *Engine) Evaluate(ctx context.Context, req Request) Decision {
+	e.mu.RLock()
+	policies := append(append([]policy(nil), e.policies[req.Type+"#*"]...), e.policies[req.Type+"#"+req.Action]...)
+	e.mu.RUnlock()
+
+	d := Decision{
+		SubjectID: req.Subject.ID,
+		Type:      req.Type,
+		Action:    req.Action,
+		Reason:    "没有允许该操作的策略",
+		Results:   make([]Result, 0, len(policies)),
+		Time:      time.Now(),
+	}
+	if id, ok := req.Env["requestId"].(string); ok {
+		d.RequestID = id
+	}
+	deny := false
+	for _, p := range policies {
+		effect, reason := p.rule(ctx, req)
+		d.Results = append(d.Results, Result{Policy: p.name, Effect: effect, Reason: reason})
+		switch {
+		case effect == Deny && !deny:
+			deny = true
+			d.Allowed = false
+			d.Reason = "策略 " + p.name + " 拒绝: " + reason
+		case effect == Allow && !deny && !d.Allowed:
+			d.Allowed = true
+			d.Reason = "策略 " + p.name + " 允许: " + reason
+		}
+	}
+	if e.Log != nil {
+		e.Log(ctx, d)
+	}
+	return d
+}
+
+// AllowLevel 主体等级不低于 min 时允许
+func AllowLevel(min int) Rule {
+	return func(_ context.Context, req Request) (Effect, string) {
+		if req.Subject.ID != 0 && req.Subject.Level >= min {
+			return Allow, fmt.Sprintf("等级 %d 不低于 %d", req.Subject.Level, min)
+		}
+		return Abstain, ""
+	}
+}
+
+// AllowPermission 主体拥有 prefix:<action> 权限时允许
+func AllowPermission(prefix string) Rule {
+	return func(_ context.Context, req Request) (Effect, string) {
+		required := prefix + ":" + req.Action
+		if req.Subject.Permissions.Has(required) {
+			return Allow, "拥有权限 " + required
+		}
+		return Abstain, ""
+	}
+}
+
+// AllowOwner 资源归属主体时允许，owner 返回资源归属的用户，资源类型不符时 ok 为 false
+func AllowOwner(owner func(resource any) (userID int, ok bool)) Rule {
+	return func(_ context.Context, req Request) (Effect, string) {
+		if req.Resource == nil || req.Subject.ID == 0 {
+			return Abstain, ""
+		}
+		if id, ok := owner(req.Resource); ok && id == req.Subject.ID {
+			return Allow, "资源归属当前用户"
+		}
+		return Abstain, ""
+	}
+}
@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"template/pkg/permission"
+)
+
+type doc struct{ owner int }
+
+func TestEngine(t *testing.T) {
+	e := New()
+	var logged []Decision
+	e.Log = func(_ context.Context, d Decision) { logged = append(logged, d) }
+
+	e.Register("doc", "*", "admin", AllowLevel(10))
+	e.Register("doc", "*", "owner", AllowOwner(func(r any) (int, bool) {
+		d, ok := r.(*doc)
+		if !ok {
+			return 0, false
+		}
+		return d.owner, true
+	}))
+	e.Register("doc", "*", "permission", AllowPermission("doc"))
+	e.Register("doc", "delete", "office-hours", func(_ context.Context, req Request) (Effect, string) {
+		if req.Env["offHours"] == true {
+			return Deny, "非工作时间禁止删除"
+		}
+		return Abstain, ""
+	})
+
+	cases := []struct {
+		name    string
+		req     Request
+		allowed bool
+	}{
+		{"owner", Request{Subject: Subject{ID: 1, Level: 1}, Action: "update", Resource: &doc{owner: 1}}, true},
+		{"other user", Request{Subject: Subject{ID: 2, Level: 1}, Action: "update", Resource: &doc{owner: 1}}, false},
+		{"admin", Request{Subject: Subject{ID: 3, Level: 10}, Action: "update", Resource: &doc{owner: 1}}, true},
+		{"permission", Request{Subject: Subject{ID: 2, Level: 1, Permissions: permission.Set{"doc:*"}}, Action: "update", Resource: &doc{owner: 1}}, true},
+		{"anonymous", Request{Action: "update", Resource: &doc{}}, false},
+		{"deny overrides", Request{Subject: Subject{ID: 3, Level: 10}, Action: "delete", Resource: &doc{owner: 1}, Env: map[string]any{"offHours": true}}, false},
+		{"unknown action", Request{Subject: Subject{ID: 1, Level: 1}, Type: "other", Action: "update"}, false},
+	}
+	for _, tc := range cases {
+		if tc.req.Type == "" {
+			tc.req.Type = "doc"
+		}
+		d := e.Evaluate(context.Background(), tc.req)
+		if d.Allowed != tc.allowed {
+			t.Errorf("%s: allowed = %v, reason %q", tc.name, d.Allowed, d.Reason)
+		}
+	}
+
+	if len(logged) != len(cases) {
+		t.Fatalf("logged %d decisions", len(logged))
+	}
+	last := logged[5]
+	if len(last.Results) != 4 || last.Results[3].Effect != Deny || last.Reason != "策略 office-hours 拒绝: 非工作时间禁止删除" {
+		t.Fatalf("unexpected decision %+v", last)
+	}
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Record 数据库中的限流计数
+type Record struct {
+	Key       string    `gorm:"primaryKey;type:VARCHAR(191);NOT NULL;comment:限流键"`
+	Start     time.Time `gorm:"type:DATETIME(6);NOT NULL;index;comment:窗口起点或上次补充令牌的时间"`
+	Count     float64   `gorm:"NOT NULL;default:0;comment:当前窗口计数或剩余令牌"`
+	Previous  float64   `gorm:"NOT NULL;default:0;comment:上一窗口计数"`
+	ExpiresAt time.Time `gorm:"type:DATETIME(3);NOT NULL;index;comment:过期时间"`
+}
+
+func (Record) TableName() string {
+	return "rate_limit"
+}
+
+// GormStore 使用数据库存储，多实例部署时共享计数，每次判断需要一个加锁的事务
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// Migrate 创建限流计数表
+func (s *GormStore) Migrate() error {
+	return s.db.AutoMigrate(&Record{})
+}
+
+func (s *GormStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	key = limit.Algorithm.String() + ":" + key
+	var result Result
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// 先确保记录存在，再加锁读取，避免并发的首次请求互相覆盖
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&Record{Key: key, ExpiresAt: now}).Error; err != nil {
+			return err
+		}
+		var record Record
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&record, "`key` = ?", key).Error; err != nil {
+			return err
+		}
+		st := state{Start: record.Start, Count: record.Count, Previous: record.Previous}
+		if !now.Before(record.ExpiresAt) {
+			st = state{}
+		}
+		result = limit.apply(&st, now)
+		return tx.Model(&record).Updates(map[string]any{
+			"start":      st.Start,
+			"count":      st.Count,
+			"previous":   st.Previous,
+			"expires_at": st.Start.Add(2 * limit.Period),
+		}).Error
+	})
+	return result, err
+}
+
+// GC 删除已过期的计数
+func (s *GormStore) GC(ctx context.Context) error {
+	return s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&Record{}).Error
+}
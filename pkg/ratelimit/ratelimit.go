@@ -0,0 +1,157 @@
+// Package ratelimit 提供滑动窗口与令牌桶限流，计数可保存在进程内存、数据库或 Redis 协议服务中
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Algorithm 限流算法
+type Algorithm int
+
+const (
+	// SlidingWindow 滑动窗口，使用当前与上一个固定窗口的加权计数近似，Period 内最多 Limit 次
+	SlidingWindow Algorithm = iota
+	// TokenBucket 令牌桶，容量为 Limit，每 Period 补满，允许突发
+	TokenBucket
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case SlidingWindow:
+		return "sliding-window"
+	case TokenBucket:
+		return "token-bucket"
+	}
+	return fmt.Sprintf("Algorithm(%d)", int(a))
+}
+
+// Limit 限流规则
+type Limit struct {
+	Algorithm Algorithm
+	Limit     int
+	Period    time.Duration
+}
+
+// Store 限流计数的存储，Allow 判断 key 是否未超过 limit，允许时计数
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Result 一次限流判断的结果
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // 被拒绝时距下次可请求的时间
+	Reset      time.Duration // 距额度完全恢复的时间
+}
+
+// state 一个 key 的计数，滑动窗口中 Start 为当前窗口起点、Count 与 Previous 为当前及上一窗口的计数
+// 令牌桶中 Start 为上次补充令牌的时间、Count 为剩余令牌
+type state struct {
+	Start    time.Time
+	Count    float64
+	Previous float64
+}
+
+// apply 按规则更新计数并返回结果，数据库存储在加锁后调用
+func (l Limit) apply(s *state, now time.Time) Result {
+	if l.Algorithm == TokenBucket {
+		return l.bucket(s, now)
+	}
+	return l.window(s, now)
+}
+
+func (l Limit) window(s *state, now time.Time) Result {
+	per := l.Period
+	if elapsed := now.Sub(s.Start); s.Start.IsZero() || elapsed >= 2*per {
+		s.Previous, s.Count = 0, 0
+		s.Start = now.Truncate(per)
+	} else if elapsed >= per {
+		s.Previous, s.Count = s.Count, 0
+		s.Start = now.Truncate(per)
+	}
+
+	weight := 1 - float64(now.Sub(s.Start))/float64(per)
+	estimated := int(s.Previous*weight) + int(s.Count)
+	reset := s.Start.Add(per).Sub(now)
+	if estimated >= l.Limit {
+		return Result{Allowed: false, Limit: l.Limit, RetryAfter: reset, Reset: reset}
+	}
+	s.Count++
+	return Result{Allowed: true, Limit: l.Limit, Remaining: l.Limit - estimated - 1, Reset: reset}
+}
+
+func (l Limit) bucket(s *state, now time.Time) Result {
+	capacity := float64(l.Limit)
+	rate := capacity / float64(l.Period) // 每纳秒补充的令牌
+	if s.Start.IsZero() {
+		s.Count = capacity
+	} else {
+		s.Count = math.Min(capacity, s.Count+float64(now.Sub(s.Start))*rate)
+	}
+	s.Start = now
+
+	if s.Count < 1 {
+		retry := time.Duration(math.Ceil((1 - s.Count) / rate))
+		return Result{Allowed: false, Limit: l.Limit, RetryAfter: retry, Reset: time.Duration((capacity - s.Count) / rate)}
+	}
+	s.Count--
+	return Result{Allowed: true, Limit: l.Limit, Remaining: int(s.Count), Reset: time.Duration((capacity - s.Count) / rate)}
+}
+
+// idle 计数已不影响结果，可以删除
+func (l Limit) idle(s *state, now time.Time) bool {
+	return now.Sub(s.Start) >= 2*l.Period
+}
+
+// Memory 进程内滑动窗口限流
+type Memory struct {
+	mu      sync.Mutex
+	windows map[string]*window
+	now     func() time.Time
+	calls   int
+}
+
+type window struct {
+	state
+	limit Limit
+}
+
+func NewMemory() *Memory {
+	return &Memory{windows: make(map[string]*window), now: time.Now}
+}
+
+// Allow 判断 key 在 per 时间内是否未超过 limit 次，允许时计数
+func (m *Memory) Allow(key string, limit int, per time.Duration) Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.calls++
+	if m.calls%1024 == 0 {
+		m.cleanup(now)
+	}
+
+	l := Limit{Algorithm: SlidingWindow, Limit: limit, Period: per}
+	w, ok := m.windows[key]
+	if !ok || w.limit.Period != per {
+		w = &window{limit: l}
+		m.windows[key] = w
+	}
+	w.limit = l
+	return l.apply(&w.state, now)
+}
+
+// cleanup 删除两个窗口内未使用的计数，调用方需持有锁
+func (m *Memory) cleanup(now time.Time) {
+	for key, w := range m.windows {
+		if w.limit.idle(&w.state, now) {
+			delete(m.windows, key)
+		}
+	}
+}
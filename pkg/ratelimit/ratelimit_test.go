@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMemory_Allow(t *testing.T) {
+	m := NewMemory()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if r := m.Allow("k", 3, time.Minute); !r.Allowed || r.Remaining != 2-i {
+			t.Fatalf("request %d should be allowed: %+v", i, r)
+		}
+	}
+	r := m.Allow("k", 3, time.Minute)
+	if r.Allowed || r.RetryAfter != time.Minute {
+		t.Fatalf("4th request should be limited: %+v", r)
+	}
+	if !m.Allow("other", 3, time.Minute).Allowed {
+		t.Fatal("keys should be independent")
+	}
+
+	// 进入下一个窗口的一半，上一窗口的计数按一半计算
+	now = now.Add(90 * time.Second)
+	for i := 0; i < 2; i++ {
+		if r := m.Allow("k", 3, time.Minute); !r.Allowed {
+			t.Fatalf("should allow after window slides: %+v", r)
+		}
+	}
+	if r := m.Allow("k", 3, time.Minute); r.Allowed {
+		t.Fatalf("weighted previous window should still count: %+v", r)
+	}
+
+	now = now.Add(3 * time.Minute)
+	if r := m.Allow("k", 3, time.Minute); !r.Allowed || r.Remaining != 2 {
+		t.Fatalf("old windows should be forgotten: %+v", r)
+	}
+}
+
+func TestSharded_TokenBucket(t *testing.T) {
+	s := NewSharded(4)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+	limit := Limit{Algorithm: TokenBucket, Limit: 10, Period: 10 * time.Second}
+
+	// 桶初始为满，允许突发
+	for i := 0; i < 10; i++ {
+		if r, _ := s.Allow(ctx, "k", limit); !r.Allowed || r.Remaining != 9-i {
+			t.Fatalf("request %d should be allowed: %+v", i, r)
+		}
+	}
+	r, _ := s.Allow(ctx, "k", limit)
+	if r.Allowed || r.RetryAfter != time.Second || r.Reset != 10*time.Second {
+		t.Fatalf("empty bucket should be limited: %+v", r)
+	}
+
+	now = now.Add(2500 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if r, _ := s.Allow(ctx, "k", limit); !r.Allowed {
+			t.Fatalf("refilled token %d should be allowed: %+v", i, r)
+		}
+	}
+	if r, _ := s.Allow(ctx, "k", limit); r.Allowed || r.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("partial token should not be used: %+v", r)
+	}
+
+	window := Limit{Algorithm: SlidingWindow, Limit: 2, Period: time.Minute}
+	s.Allow(ctx, "w", window)
+	s.Allow(ctx, "w", window)
+	if r, _ := s.Allow(ctx, "w", window); r.Allowed {
+		t.Fatalf("sliding window should be limited: %+v", r)
+	}
+}
+
+func TestRedis_SlidingWindow(t *testing.T) {
+	srv := miniredis.RunT(t)
+	r := NewRedis(redis.NewClient(&redis.Options{Addr: srv.Addr()}), "test:")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+	ctx := context.Background()
+	limit := Limit{Algorithm: SlidingWindow, Limit: 3, Period: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		if res, err := r.Allow(ctx, "k", limit); err != nil || !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d should be allowed: %+v %v", i, res, err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if res, _ := r.Allow(ctx, "k", limit); res.Allowed || res.RetryAfter != time.Minute {
+			t.Fatalf("request should be limited: %+v", res)
+		}
+	}
+
+	// 被拒绝的请求不计数，下一窗口过半时上一窗口按一半计算
+	now = now.Add(90 * time.Second)
+	srv.FastForward(90 * time.Second)
+	for i := 0; i < 2; i++ {
+		if res, _ := r.Allow(ctx, "k", limit); !res.Allowed || res.Remaining != 1-i {
+			t.Fatalf("should allow after window slides: %+v", res)
+		}
+	}
+	if res, _ := r.Allow(ctx, "k", limit); res.Allowed {
+		t.Fatalf("weighted previous window should still count: %+v", res)
+	}
+}
+
+func TestRedis_TokenBucket(t *testing.T) {
+	srv := miniredis.RunT(t)
+	r := NewRedis(redis.NewClient(&redis.Options{Addr: srv.Addr()}), "test:")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+	ctx := context.Background()
+	limit := Limit{Algorithm: TokenBucket, Limit: 10, Period: 10 * time.Second}
+
+	for i := 0; i < 10; i++ {
+		if res, err := r.Allow(ctx, "k", limit); err != nil || !res.Allowed || res.Remaining != 9-i {
+			t.Fatalf("request %d should be allowed: %+v %v", i, res, err)
+		}
+	}
+	if res, _ := r.Allow(ctx, "k", limit); res.Allowed || res.RetryAfter != time.Second {
+		t.Fatalf("empty bucket should be limited: %+v", res)
+	}
+
+	now = now.Add(2500 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if res, _ := r.Allow(ctx, "k", limit); !res.Allowed {
+			t.Fatalf("refilled token %d should be allowed: %+v", i, res)
+		}
+	}
+	if res, _ := r.Allow(ctx, "k", limit); res.Allowed {
+		t.Fatalf("partial token should not be used: %+v", res)
+	}
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 使用 Redis 协议服务存储，多实例部署时共享计数
+// 滑动窗口只使用 INCR 等基础命令，令牌桶需要服务端支持 EVAL
+type Redis struct {
+	client redis.Cmdable
+	prefix string
+	now    func() time.Time
+}
+
+func NewRedis(client redis.Cmdable, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix, now: time.Now}
+}
+
+// tokenBucketScript 在服务端原子地补充并消耗令牌，时间由调用方传入
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local v = redis.call('HMGET', KEYS[1], 't', 'ts')
+local tokens = tonumber(v[1]) or capacity
+local ts = tonumber(v[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 't', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity / rate))
+return {allowed, tostring(tokens)}
+`)
+
+func (r *Redis) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if limit.Algorithm == TokenBucket {
+		return r.bucket(ctx, key, limit)
+	}
+	return r.window(ctx, key, limit)
+}
+
+func (r *Redis) window(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := r.now()
+	per := limit.Period
+	start := now.Truncate(per)
+	current := r.prefix + "sw:" + key + ":" + strconv.FormatInt(start.UnixMilli(), 10)
+	previous := r.prefix + "sw:" + key + ":" + strconv.FormatInt(start.Add(-per).UnixMilli(), 10)
+
+	count, err := r.client.Incr(ctx, current).Result()
+	if err != nil {
+		return Result{}, err
+	}
+	if count == 1 {
+		if err := r.client.PExpire(ctx, current, 2*per).Err(); err != nil {
+			return Result{}, err
+		}
+	}
+	prev, err := r.client.Get(ctx, previous).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return Result{}, err
+	}
+
+	weight := 1 - float64(now.Sub(start))/float64(per)
+	estimated := int(float64(prev)*weight) + int(count) - 1
+	reset := start.Add(per).Sub(now)
+	if estimated >= limit.Limit {
+		// 被拒绝的请求不计数
+		if err := r.client.Decr(ctx, current).Err(); err != nil {
+			return Result{}, err
+		}
+		return Result{Allowed: false, Limit: limit.Limit, RetryAfter: reset, Reset: reset}, nil
+	}
+	return Result{Allowed: true, Limit: limit.Limit, Remaining: limit.Limit - estimated - 1, Reset: reset}, nil
+}
+
+func (r *Redis) bucket(ctx context.Context, key string, limit Limit) (Result, error) {
+	capacity := float64(limit.Limit)
+	rate := capacity / float64(limit.Period.Milliseconds()) // 每毫秒补充的令牌
+	reply, err := tokenBucketScript.Run(ctx, r.client, []string{r.prefix + "tb:" + key},
+		capacity, rate, r.now().UnixMilli()).Result()
+	if err != nil {
+		return Result{}, err
+	}
+	items, ok := reply.([]any)
+	if !ok || len(items) != 2 {
+		return Result{}, errors.New("ratelimit: unexpected redis reply")
+	}
+	allowed, _ := items[0].(int64)
+	s, _ := items[1].(string)
+	tokens, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Result{}, err
+	}
+
+	ms := func(v float64) time.Duration { return time.Duration(v * float64(time.Millisecond)) }
+	result := Result{Allowed: allowed == 1, Limit: limit.Limit, Remaining: int(tokens), Reset: ms((capacity - tokens) / rate)}
+	if !result.Allowed {
+		result.RetryAfter = ms((1 - tokens) / rate)
+	}
+	return result, nil
+}
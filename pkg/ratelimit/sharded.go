@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// Sharded 进程内存储，按 key 哈希分片加锁以减少并发请求间的竞争，仅适用于单实例部署
+type Sharded struct {
+	seed   maphash.Seed
+	shards []*shard
+	now    func() time.Time
+}
+
+type shard struct {
+	mu      sync.Mutex
+	entries map[string]*window
+	calls   int
+}
+
+// NewSharded 创建 n 个分片的存储，n 不大于 0 时使用 64
+func NewSharded(n int) *Sharded {
+	if n <= 0 {
+		n = 64
+	}
+	s := &Sharded{seed: maphash.MakeSeed(), shards: make([]*shard, n), now: time.Now}
+	for i := range s.shards {
+		s.shards[i] = &shard{entries: make(map[string]*window)}
+	}
+	return s
+}
+
+func (s *Sharded) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	sh := s.shards[maphash.String(s.seed, key)%uint64(len(s.shards))]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	now := s.now()
+	sh.calls++
+	if sh.calls%1024 == 0 {
+		for k, w := range sh.entries {
+			if w.limit.idle(&w.state, now) {
+				delete(sh.entries, k)
+			}
+		}
+	}
+
+	w, ok := sh.entries[key]
+	if !ok || w.limit != limit {
+		w = &window{limit: limit}
+		sh.entries[key] = w
+	}
+	return limit.apply(&w.state, now), nil
+}
@@ -0,0 +1,137 @@
+package sessionstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MemoryBackend 进程内存储，仅适用于单实例部署及测试
+type MemoryBackend struct {
+	mu    sync.RWMutex
+	items map[string]memoryItem
+	now   func() time.Time
+}
+
+type memoryItem struct {
+	data     []byte
+	expireAt time.Time
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{items: make(map[string]memoryItem), now: time.Now}
+}
+
+func (b *MemoryBackend) Load(_ context.Context, id string) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	item, ok := b.items[id]
+	if !ok || !b.now().Before(item.expireAt) {
+		return nil, ErrNotFound
+	}
+	return item.data, nil
+}
+
+func (b *MemoryBackend) Save(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.items[id] = memoryItem{data: data, expireAt: b.now().Add(ttl)}
+	return nil
+}
+
+func (b *MemoryBackend) Delete(_ context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.items, id)
+	return nil
+}
+
+func (b *MemoryBackend) GC(_ context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	for id, item := range b.items {
+		if !now.Before(item.expireAt) {
+			delete(b.items, id)
+		}
+	}
+	return nil
+}
+
+// RedisBackend 使用 Redis 存储，过期由服务端处理
+type RedisBackend struct {
+	client redis.Cmdable
+	prefix string
+}
+
+func NewRedisBackend(client redis.Cmdable, prefix string) *RedisBackend {
+	return &RedisBackend{client: client, prefix: prefix}
+}
+
+func (b *RedisBackend) Load(ctx context.Context, id string) ([]byte, error) {
+	data, err := b.client.Get(ctx, b.prefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (b *RedisBackend) Save(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	return b.client.Set(ctx, b.prefix+id, data, ttl).Err()
+}
+
+func (b *RedisBackend) Delete(ctx context.Context, id string) error {
+	return b.client.Del(ctx, b.prefix+id).Err()
+}
+
+// SessionRecord 数据库中的会话记录
+type SessionRecord struct {
+	ID        string    `gorm:"primaryKey;type:VARCHAR(64);NOT NULL;comment:会话ID"`
+	Data      []byte    `gorm:"type:BLOB;NOT NULL;comment:会话数据"`
+	ExpiresAt time.Time `gorm:"type:DATETIME(3);NOT NULL;index;comment:过期时间"`
+}
+
+func (SessionRecord) TableName() string {
+	return "session"
+}
+
+// GormBackend 使用数据库存储，需要定期 GC 清理过期记录
+type GormBackend struct {
+	db *gorm.DB
+}
+
+func NewGormBackend(db *gorm.DB) *GormBackend {
+	return &GormBackend{db: db}
+}
+
+// Migrate 创建会话表
+func (b *GormBackend) Migrate() error {
+	return b.db.AutoMigrate(&SessionRecord{})
+}
+
+func (b *GormBackend) Load(ctx context.Context, id string) ([]byte, error) {
+	var record SessionRecord
+	err := b.db.WithContext(ctx).Where("id = ? AND expires_at > ?", id, time.Now()).Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return record.Data, err
+}
+
+func (b *GormBackend) Save(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	record := SessionRecord{ID: id, Data: data, ExpiresAt: time.Now().Add(ttl)}
+	return b.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&record).Error
+}
+
+func (b *GormBackend) Delete(ctx context.Context, id string) error {
+	return b.db.WithContext(ctx).Delete(&SessionRecord{ID: id}).Error
+}
+
+func (b *GormBackend) GC(ctx context.Context) error {
+	return b.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&SessionRecord{}).Error
+}
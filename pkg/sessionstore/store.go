@@ -0,0 +1,177 @@
+package sessionstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+)
+
+// ErrNotFound 会话不存在或已过期
+var ErrNotFound = errors.New("sessionstore: session not found")
+
+// RegenerateKey 会话中存在该键时，保存时会删除旧会话并更换会话ID，用于登录及权限变更时防止会话固定
+const RegenerateKey = "_sessionstore_regenerate"
+
+// Backend 服务端会话数据的存储后端
+type Backend interface {
+	Load(ctx context.Context, id string) ([]byte, error)
+	Save(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Collector 需要主动清理过期会话的后端实现该接口
+type Collector interface {
+	GC(ctx context.Context) error
+}
+
+// Store 服务端会话存储，Cookie 中只保存签名后的会话ID，实现了 sessions.Store
+type Store struct {
+	Codecs  []securecookie.Codec
+	backend Backend
+	options *gsessions.Options
+}
+
+var _ sessions.Store = (*Store)(nil)
+
+// New 创建会话存储，keyPairs 与 cookie.NewStore 相同，用于签名会话ID
+// 会话的有效期由后端 TTL 决定，签名本身不校验时间，以便各会话使用不同的 MaxAge
+func New(backend Backend, keyPairs ...[]byte) *Store {
+	s := &Store{
+		Codecs:  securecookie.CodecsFromPairs(keyPairs...),
+		backend: backend,
+		options: &gsessions.Options{Path: "/", MaxAge: 86400 * 30},
+	}
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(0)
+		}
+	}
+	return s
+}
+
+// Backend 返回存储后端
+func (s *Store) Backend() Backend {
+	return s.backend
+}
+
+func (s *Store) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+}
+
+func (s *Store) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+// New 读取请求中的会话，Cookie 无效或会话已过期时返回新会话
+func (s *Store) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	options := *s.options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	var id string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &id, s.Codecs...); err != nil {
+		return session, nil
+	}
+	data, err := s.backend.Load(r.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		return session, nil
+	}
+	if err != nil {
+		return session, err
+	}
+	// 无法解码时（如类型未注册）按新会话处理，不保留部分解码的数据
+	values := make(map[any]any)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&values); err != nil {
+		return session, err
+	}
+	session.Values = values
+	session.ID = id
+	session.IsNew = false
+	return session, nil
+}
+
+// Save 写入会话数据并设置 Cookie，MaxAge 小于等于 0 时删除会话
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	if session.Options.MaxAge <= 0 {
+		if session.ID != "" {
+			if err := s.backend.Delete(r.Context(), session.ID); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if _, ok := session.Values[RegenerateKey]; ok {
+		delete(session.Values, RegenerateKey)
+		if session.ID != "" {
+			if err := s.backend.Delete(r.Context(), session.ID); err != nil {
+				return err
+			}
+			session.ID = ""
+		}
+	}
+	if session.ID == "" {
+		session.ID = NewID()
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(session.Values); err != nil {
+		return err
+	}
+	ttl := time.Duration(session.Options.MaxAge) * time.Second
+	if err := s.backend.Save(r.Context(), session.ID, buf.Bytes(), ttl); err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// StartGC 定期清理过期会话，返回停止函数，后端未实现 Collector 时不做任何事
+func (s *Store) StartGC(interval time.Duration, onError func(error)) (stop func()) {
+	collector, ok := s.backend.(Collector)
+	if !ok {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := collector.GC(ctx); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	return cancel
+}
+
+// NewID 生成随机会话ID
+func NewID() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
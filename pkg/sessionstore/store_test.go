@@ -0,0 +1,144 @@
+package sessionstore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func setupRouter(store *Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	store.Options(sessions.Options{Path: "/", MaxAge: 60})
+	r.Use(sessions.Sessions("test-session", store))
+	r.POST("/set", func(c *gin.Context) {
+		s := sessions.Default(c)
+		s.Set("name", "bob")
+		if err := s.Save(); err != nil {
+			c.String(500, err.Error())
+			return
+		}
+		c.String(200, "ok")
+	})
+	r.POST("/regenerate", func(c *gin.Context) {
+		s := sessions.Default(c)
+		s.Set(RegenerateKey, true)
+		if err := s.Save(); err != nil {
+			c.String(500, err.Error())
+			return
+		}
+		c.String(200, "ok")
+	})
+	r.GET("/get", func(c *gin.Context) {
+		v, _ := sessions.Default(c).Get("name").(string)
+		c.String(200, v)
+	})
+	r.POST("/logout", func(c *gin.Context) {
+		s := sessions.Default(c)
+		s.Clear()
+		s.Options(sessions.Options{Path: "/", MaxAge: -1})
+		if err := s.Save(); err != nil {
+			c.String(500, err.Error())
+			return
+		}
+		c.String(200, "ok")
+	})
+	return r
+}
+
+func do(r *gin.Engine, method, path, cookie string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func sessionCookie(t *testing.T, w *httptest.ResponseRecorder) string {
+	cookie := w.Header().Get("Set-Cookie")
+	if cookie == "" {
+		t.Fatal("missing Set-Cookie")
+	}
+	return strings.Split(cookie, ";")[0]
+}
+
+func testRoundTrip(t *testing.T, backend Backend) {
+	r := setupRouter(New(backend, []byte("secret")))
+
+	w := do(r, http.MethodPost, "/set", "")
+	if w.Code != 200 {
+		t.Fatalf("set failed: %d %s", w.Code, w.Body.String())
+	}
+	cookie := sessionCookie(t, w)
+	if strings.Contains(cookie, "bob") {
+		t.Fatal("session data should not be stored in cookie")
+	}
+
+	if body := do(r, http.MethodGet, "/get", cookie).Body.String(); body != "bob" {
+		t.Fatalf("unexpected session value %q", body)
+	}
+	if body := do(r, http.MethodGet, "/get", cookie+"x").Body.String(); body != "" {
+		t.Fatalf("tampered cookie should start a new session, got %q", body)
+	}
+
+	w = do(r, http.MethodPost, "/regenerate", cookie)
+	regenerated := sessionCookie(t, w)
+	if regenerated == cookie {
+		t.Fatal("session id should change after regeneration")
+	}
+	if body := do(r, http.MethodGet, "/get", cookie).Body.String(); body != "" {
+		t.Fatalf("old session id should be invalid, got %q", body)
+	}
+	if body := do(r, http.MethodGet, "/get", regenerated).Body.String(); body != "bob" {
+		t.Fatalf("regenerated session should keep data, got %q", body)
+	}
+	cookie = regenerated
+
+	do(r, http.MethodPost, "/logout", cookie)
+	if body := do(r, http.MethodGet, "/get", cookie).Body.String(); body != "" {
+		t.Fatalf("session should be revoked server side, got %q", body)
+	}
+}
+
+func TestStore_Memory(t *testing.T) {
+	testRoundTrip(t, NewMemoryBackend())
+}
+
+func TestStore_Redis(t *testing.T) {
+	srv := miniredis.RunT(t)
+	testRoundTrip(t, NewRedisBackend(redis.NewClient(&redis.Options{Addr: srv.Addr()}), "test:"))
+}
+
+func TestMemoryBackend_ExpiryAndGC(t *testing.T) {
+	b := NewMemoryBackend()
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_ = b.Save(ctx, "a", []byte("1"), time.Minute)
+	_ = b.Save(ctx, "b", []byte("2"), time.Hour)
+	now = now.Add(2 * time.Minute)
+
+	if _, err := b.Load(ctx, "a"); err != ErrNotFound {
+		t.Fatalf("expected expired session, got %v", err)
+	}
+	if err := b.GC(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.items) != 1 {
+		t.Fatalf("expected 1 session after gc, got %d", len(b.items))
+	}
+	if data, err := b.Load(ctx, "b"); err != nil || string(data) != "2" {
+		t.Fatalf("unexpected session %q %v", data, err)
+	}
+}
@@ -0,0 +1,62 @@
+// Package sms 定义短信服务商接口及手机号处理
+package sms
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Message 一条模板短信，Template 为业务模板名，由服务商实现映射为各自的模板ID
+type Message struct {
+	Phone    string
+	Template string
+	Params   map[string]string
+}
+
+// Provider 短信服务商
+type Provider interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// ErrInvalidPhone 不是有效的中国大陆手机号
+var ErrInvalidPhone = errors.New("sms: invalid mobile number")
+
+var mobilePattern = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// NormalizePhone 去除空格、短横线及 +86/0086 前缀，返回 11 位手机号
+func NormalizePhone(phone string) (string, error) {
+	phone = strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(phone))
+	for _, prefix := range []string{"+86", "0086"} {
+		phone = strings.TrimPrefix(phone, prefix)
+	}
+	if !mobilePattern.MatchString(phone) {
+		return "", ErrInvalidPhone
+	}
+	return phone, nil
+}
+
+// Mask 隐藏手机号中间四位
+func Mask(phone string) string {
+	if len(phone) != 11 {
+		return phone
+	}
+	return phone[:3] + "****" + phone[7:]
+}
+
+// Log 只记录日志不实际发送，用于开发环境，手机号脱敏且不记录参数值，避免验证码写入日志
+type Log struct {
+	Logf func(format string, args ...any)
+}
+
+func (l *Log) Send(_ context.Context, msg Message) error {
+	keys := make([]string, 0, len(msg.Params))
+	for k := range msg.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	l.Logf("sms to %s template %s: %s", Mask(msg.Phone), msg.Template, strings.Join(keys, " "))
+	return nil
+}
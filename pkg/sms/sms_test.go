@@ -0,0 +1,38 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	cases := map[string]string{
+		"13812345678":       "13812345678",
+		"+86 138-1234-5678": "13812345678",
+		"008613812345678":   "13812345678",
+		"12812345678":       "",
+		"1381234567":        "",
+		"phone":             "",
+	}
+	for in, want := range cases {
+		got, err := NormalizePhone(in)
+		if (err != nil) != (want == "") || got != want {
+			t.Errorf("NormalizePhone(%q) = %q, %v", in, got, err)
+		}
+	}
+	if Mask("13812345678") != "138****5678" {
+		t.Errorf("unexpected mask %q", Mask("13812345678"))
+	}
+}
+
+func TestLog(t *testing.T) {
+	var out string
+	l := &Log{Logf: func(format string, args ...any) { out = fmt.Sprintf(format, args...) }}
+	if err := l.Send(context.Background(), Message{Phone: "13812345678", Template: "login", Params: map[string]string{"code": "123456", "minutes": "5"}}); err != nil {
+		t.Fatal(err)
+	}
+	if out != "sms to 138****5678 template login: code minutes" {
+		t.Fatalf("unexpected log %q", out)
+	}
+}
@@ -0,0 +1,59 @@
+package totp
+
+import (
+	"bytes"
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量，取低 6 位
+func TestCodeAt_RFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := CodeAt(secret, Counter(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%d: got %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidate_Skew(t *testing.T) {
+	secret, _ := NewSecret()
+	now := time.Now()
+	prev, _ := CodeAt(secret, Counter(now)-1)
+	if counter, ok := Validate(secret, prev, now, 1); !ok || counter != Counter(now)-1 {
+		t.Fatalf("previous step should be accepted within skew: %v %v", counter, ok)
+	}
+	old, _ := CodeAt(secret, Counter(now)-3)
+	if _, ok := Validate(secret, old, now, 1); ok {
+		t.Fatal("code outside skew window should be rejected")
+	}
+	if _, ok := Validate(secret, "12345", now, 1); ok {
+		t.Fatal("short code should be rejected")
+	}
+}
+
+func TestURIAndQRCode(t *testing.T) {
+	uri := URI("tz gin", "bob@example.com", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/tz%20gin:bob@example.com?") || !strings.Contains(uri, "secret=ABC") {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+	png, err := QRCode(uri, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Fatal("expected png output")
+	}
+}
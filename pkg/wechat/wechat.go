@@ -0,0 +1,173 @@
+// Package wechat 提供微信小程序登录 code2session 及加密数据解密
+package wechat
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL 微信接口地址
+const DefaultBaseURL = "https://api.weixin.qq.com"
+
+var (
+	// ErrDecrypt 加密数据无法解密，通常是 session_key 已过期
+	ErrDecrypt = errors.New("wechat: decrypt failed")
+	// ErrWatermark 解密数据的 appid 与当前小程序不一致
+	ErrWatermark = errors.New("wechat: watermark mismatch")
+)
+
+// Error 微信接口返回的错误
+type Error struct {
+	Code    int    `json:"errcode"`
+	Message string `json:"errmsg"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("wechat: %d %s", e.Code, e.Message)
+}
+
+// Client 小程序接口客户端，BaseURL 为空时使用 DefaultBaseURL
+type Client struct {
+	AppID      string
+	Secret     string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// Session code2session 的结果
+type Session struct {
+	OpenID     string `json:"openid"`
+	UnionID    string `json:"unionid"`
+	SessionKey string `json:"session_key"`
+}
+
+// Watermark 加密数据中的水印
+type Watermark struct {
+	AppID     string `json:"appid"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// PhoneInfo 手机号加密数据
+type PhoneInfo struct {
+	PhoneNumber     string    `json:"phoneNumber"`
+	PurePhoneNumber string    `json:"purePhoneNumber"`
+	CountryCode     string    `json:"countryCode"`
+	Watermark       Watermark `json:"watermark"`
+}
+
+// UserInfo 用户信息加密数据
+type UserInfo struct {
+	OpenID    string    `json:"openId"`
+	UnionID   string    `json:"unionId"`
+	NickName  string    `json:"nickName"`
+	AvatarURL string    `json:"avatarUrl"`
+	Watermark Watermark `json:"watermark"`
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return strings.TrimRight(c.BaseURL, "/")
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return &http.Client{Timeout: 10 * time.Second}
+	}
+	return c.HTTPClient
+}
+
+// Code2Session 使用 wx.login 返回的 code 换取 openid 及 session_key
+func (c *Client) Code2Session(ctx context.Context, code string) (*Session, error) {
+	query := url.Values{
+		"appid":      {c.AppID},
+		"secret":     {c.Secret},
+		"js_code":    {code},
+		"grant_type": {"authorization_code"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL()+"/sns/jscode2session?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wechat: code2session status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Session
+		Error
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Code != 0 {
+		return nil, &body.Error
+	}
+	if body.OpenID == "" || body.SessionKey == "" {
+		return nil, errors.New("wechat: empty session")
+	}
+	return &body.Session, nil
+}
+
+// Decrypt 使用 session_key 解密 encryptedData，并校验水印中的 appid，v 需要包含 Watermark 字段
+func (c *Client) Decrypt(sessionKey, encryptedData, iv string, v any) error {
+	plain, err := decrypt(sessionKey, encryptedData, iv)
+	if err != nil {
+		return err
+	}
+	var mark struct {
+		Watermark Watermark `json:"watermark"`
+	}
+	if err := json.Unmarshal(plain, &mark); err != nil {
+		return ErrDecrypt
+	}
+	if mark.Watermark.AppID != c.AppID {
+		return ErrWatermark
+	}
+	return json.Unmarshal(plain, v)
+}
+
+// decrypt AES-128-CBC，PKCS#7 填充，参数均为 base64
+func decrypt(sessionKey, encryptedData, iv string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(sessionKey)
+	if err != nil || len(key) != 16 {
+		return nil, ErrDecrypt
+	}
+	data, err := base64.StdEncoding.DecodeString(encryptedData)
+	if err != nil || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, ErrDecrypt
+	}
+	ivBytes, err := base64.StdEncoding.DecodeString(iv)
+	if err != nil || len(ivBytes) != aes.BlockSize {
+		return nil, ErrDecrypt
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, ivBytes).CryptBlocks(plain, data)
+
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(plain) ||
+		!bytes.Equal(plain[len(plain)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return nil, ErrDecrypt
+	}
+	return plain[:len(plain)-pad], nil
+}
@@ -0,0 +1,57 @@
+package wechat_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"template/pkg/wechat"
+	"template/pkg/wechat/wechattest"
+)
+
+func TestCode2SessionAndDecrypt(t *testing.T) {
+	srv := wechattest.NewServer("wx-app", "wx-secret")
+	defer srv.Close()
+	ctx := context.Background()
+	client := &wechat.Client{AppID: "wx-app", Secret: "wx-secret", BaseURL: srv.URL()}
+
+	want := srv.AddCode("code-1", "openid-1", "unionid-1")
+	session, err := client.Code2Session(ctx, "code-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *session != want {
+		t.Fatalf("unexpected session %+v", session)
+	}
+	// code 只能使用一次
+	var wxErr *wechat.Error
+	if _, err := client.Code2Session(ctx, "code-1"); !errors.As(err, &wxErr) || wxErr.Code != 40029 {
+		t.Fatalf("reused code should fail with 40029, got %v", err)
+	}
+
+	data, iv, err := wechattest.Encrypt(session.SessionKey, wechat.PhoneInfo{
+		PhoneNumber:     "+86 13812345678",
+		PurePhoneNumber: "13812345678",
+		CountryCode:     "86",
+		Watermark:       wechat.Watermark{AppID: "wx-app"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var phone wechat.PhoneInfo
+	if err := client.Decrypt(session.SessionKey, data, iv, &phone); err != nil {
+		t.Fatal(err)
+	}
+	if phone.PurePhoneNumber != "13812345678" {
+		t.Fatalf("unexpected phone %+v", phone)
+	}
+
+	other := srv.AddCode("code-2", "openid-2", "")
+	if err := client.Decrypt(other.SessionKey, data, iv, &phone); !errors.Is(err, wechat.ErrDecrypt) {
+		t.Fatalf("wrong session key should fail, got %v", err)
+	}
+	data, iv, _ = wechattest.Encrypt(session.SessionKey, wechat.PhoneInfo{Watermark: wechat.Watermark{AppID: "other-app"}})
+	if err := client.Decrypt(session.SessionKey, data, iv, &phone); !errors.Is(err, wechat.ErrWatermark) {
+		t.Fatalf("foreign watermark should fail, got %v", err)
+	}
+}
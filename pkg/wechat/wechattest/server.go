@@ -0,0 +1,101 @@
+// Package wechattest 提供进程内的微信接口，用于测试小程序登录
+package wechattest
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"template/pkg/wechat"
+)
+
+// Server 模拟 code2session 接口，AddCode 登记的 code 只能使用一次
+type Server struct {
+	AppID  string
+	Secret string
+
+	server *httptest.Server
+
+	mu    sync.Mutex
+	codes map[string]wechat.Session
+}
+
+// NewServer 启动模拟接口
+func NewServer(appID, secret string) *Server {
+	s := &Server{AppID: appID, Secret: secret, codes: make(map[string]wechat.Session)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sns/jscode2session", s.code2session)
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// URL 接口地址，作为 wechat.Client 的 BaseURL
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Close 停止服务
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// AddCode 登记一个 wx.login 返回的 code，session_key 为空时随机生成，返回登记的会话
+func (s *Server) AddCode(code, openID, unionID string) wechat.Session {
+	key := make([]byte, 16)
+	rand.Read(key)
+	session := wechat.Session{OpenID: openID, UnionID: unionID, SessionKey: base64.StdEncoding.EncodeToString(key)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[code] = session
+	return session
+}
+
+func (s *Server) code2session(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	w.Header().Set("Content-Type", "application/json")
+	if q.Get("appid") != s.AppID || q.Get("secret") != s.Secret || q.Get("grant_type") != "authorization_code" {
+		json.NewEncoder(w).Encode(wechat.Error{Code: 40013, Message: "invalid appid"})
+		return
+	}
+	s.mu.Lock()
+	session, ok := s.codes[q.Get("js_code")]
+	delete(s.codes, q.Get("js_code"))
+	s.mu.Unlock()
+	if !ok {
+		json.NewEncoder(w).Encode(wechat.Error{Code: 40029, Message: "invalid code"})
+		return
+	}
+	json.NewEncoder(w).Encode(session)
+}
+
+// Encrypt 按微信的方式加密数据，v 中的水印需由调用方设置
+func Encrypt(sessionKey string, v any) (encryptedData, iv string, err error) {
+	key, err := base64.StdEncoding.DecodeString(sessionKey)
+	if err != nil {
+		return "", "", err
+	}
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return "", "", err
+	}
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	plain = append(plain, bytes.Repeat([]byte{byte(pad)}, pad)...)
+
+	ivBytes := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(ivBytes); err != nil {
+		return "", "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", "", err
+	}
+	data := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, ivBytes).CryptBlocks(data, plain)
+	return base64.StdEncoding.EncodeToString(data), base64.StdEncoding.EncodeToString(ivBytes), nil
+}
@@ -4,14 +4,26 @@ import (
 	"net/http"
 	"template/config"
 	"template/controller"
+	"template/model"
+	"template/service"
 
+	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
 func NewServer() *http.Server {
+	model.Init()
+	// 生产环境未配置 smtp 时启动失败，避免重置密码链接写入本地文件
+	if _, err := service.Mailer(); err != nil {
+		panic(err)
+	}
+	// 生产环境未配置令牌签名密钥时启动失败，避免使用由默认 APP_SECRET 派生的密钥
+	if _, err := (&service.Token{}).JWKS(); err != nil {
+		panic(err)
+	}
 	r := gin.Default()
 	config.SetCORS(r)
-	config.InitSession(r)
+	initSession(r)
 	InitRouter(r)
 	s := &http.Server{
 		Addr:    "0.0.0.0:8088",
@@ -22,3 +34,13 @@ func NewServer() *http.Server {
 }
 
 var ctr = controller.New()
+
+// initSession 使用 APP_SESSION_STORE 指定的存储启用 session
+func initSession(r *gin.Engine) {
+	store, err := service.SessionStore()
+	if err != nil {
+		panic(err)
+	}
+	store.Options(config.SessionOptions(config.Config.SessionIdleTimeout))
+	r.Use(sessions.Sessions(config.Config.SessionCookieName, store))
+}
@@ -1,21 +1,153 @@
 package router
 
 import (
+	"time"
+
+	"template/common"
 	"template/middleware"
+	"template/pkg/ratelimit"
+	"template/service"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 各接口的限流规则
+var (
+	loginLimit    = ratelimit.Limit{Algorithm: ratelimit.SlidingWindow, Limit: 20, Period: time.Minute}
+	registerLimit = ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Limit: 5, Period: 10 * time.Minute}
+	captchaLimit  = ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Limit: 30, Period: time.Minute}
+)
+
 func InitRouter(r *gin.Engine) {
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Error)
 	r.Use(middleware.GinLogger(), middleware.GinRecovery(true))
+	r.Use(middleware.SessionExpiry)
+	r.Use(middleware.BearerAuth)
+	r.Use(middleware.APIKeyAuth)
+	r.Use(middleware.CSRF)
 	apiRouter := r.Group("/api")
 	{
 		// example
 		// begin
 		apiRouter.GET("/", ctr.Hello.Hello)
 		apiRouter.GET("/time", ctr.Hello.HelloTime)
+
+		resourceRouter := apiRouter.Group("/resources", middleware.RequireScope("resources"), middleware.CheckRole(common.LevelUser),
+			middleware.RateLimit(ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Limit: 60, Period: time.Minute}, middleware.ByAPIKey),
+			middleware.ConsumeQuota(service.QuotaAPICalls))
+		{
+			resourceRouter.GET("", ctr.Resource.List)
+			resourceRouter.POST("", ctr.Resource.Create)
+			resourceRouter.GET("/:id", ctr.Resource.Get)
+			resourceRouter.PUT("/:id", ctr.Resource.Update)
+			resourceRouter.DELETE("/:id", ctr.Resource.Delete)
+		}
+
+		categoryRouter := apiRouter.Group("/categories", middleware.CheckRole(common.LevelUser))
+		{
+			categoryRouter.GET("", ctr.Category.Tree)
+			categoryRouter.GET("/:id/ancestors", ctr.Category.Ancestors)
+			categoryRouter.POST("", middleware.CheckRole(common.LevelAdmin), ctr.Category.Create)
+			categoryRouter.PUT("/:id/parent", middleware.CheckRole(common.LevelAdmin), ctr.Category.Move)
+		}
 		// end
 
+		apiRouter.GET("/csrf-token", ctr.CSRF.Token)
+		apiRouter.POST("/captcha", middleware.RateLimit(captchaLimit, middleware.ByIP), ctr.Captcha.Create)
+
+		userRouter := apiRouter.Group("/user")
+		{
+			userRouter.POST("/register", middleware.RateLimit(registerLimit, middleware.ByIP), middleware.RequireCaptcha, ctr.User.Register)
+			userRouter.POST("/login", middleware.RateLimit(loginLimit, middleware.ByIP), ctr.User.Login)
+			userRouter.POST("/logout", ctr.User.Logout)
+			userRouter.GET("/me", middleware.CheckRole(common.LevelUser), ctr.User.Me)
+			userRouter.PUT("/password", middleware.CheckRole(common.LevelUser), ctr.User.ChangePassword)
+			userRouter.POST("/password/forgot", ctr.Account.ForgotPassword)
+			userRouter.POST("/password/reset", ctr.Account.ResetPassword)
+			userRouter.PUT("/email", middleware.CheckRole(common.LevelUser), ctr.Account.SetEmail)
+			userRouter.POST("/email/verification", middleware.CheckRole(common.LevelUser), ctr.Account.SendVerification)
+			userRouter.POST("/email/verify", ctr.Account.VerifyEmail)
+			userRouter.PUT("/phone", middleware.CheckRole(common.LevelUser), ctr.SMS.Bind)
+			userRouter.GET("/permissions", middleware.CheckRole(common.LevelUser), ctr.RBAC.Permissions)
+			userRouter.GET("/quotas", middleware.CheckRole(common.LevelUser), ctr.Quota.Usage)
+		}
+
+		mfaRouter := apiRouter.Group("/user/2fa")
+		{
+			mfaRouter.POST("/verify", ctr.MFA.Verify)
+			mfaRouter.POST("/setup", middleware.CheckRole(common.LevelUser), ctr.MFA.Setup)
+			mfaRouter.GET("/qrcode", middleware.CheckRole(common.LevelUser), ctr.MFA.QRCode)
+			mfaRouter.POST("/enable", middleware.CheckRole(common.LevelUser), ctr.MFA.Enable)
+			mfaRouter.POST("/disable", middleware.CheckRole(common.LevelUser), ctr.MFA.Disable)
+			mfaRouter.POST("/recovery-codes", middleware.CheckRole(common.LevelUser), ctr.MFA.RecoveryCodes)
+		}
+
+		smsRouter := apiRouter.Group("/sms")
+		{
+			smsRouter.POST("/code", middleware.RequireCaptcha, ctr.SMS.SendCode)
+			smsRouter.POST("/login", ctr.SMS.Login)
+		}
+
+		wechatRouter := apiRouter.Group("/wechat")
+		{
+			wechatRouter.POST("/login", ctr.Wechat.Login)
+			wechatRouter.POST("/phone", middleware.CheckRole(common.LevelUser), ctr.Wechat.BindPhone)
+			wechatRouter.POST("/userinfo", middleware.CheckRole(common.LevelUser), ctr.Wechat.UserInfo)
+		}
+
+		oidcRouter := apiRouter.Group("/oidc")
+		{
+			oidcRouter.GET("/:provider/login", ctr.OIDC.Login)
+			oidcRouter.GET("/:provider/callback", ctr.OIDC.Callback)
+		}
+
+		tokenRouter := apiRouter.Group("/token")
+		{
+			tokenRouter.POST("", middleware.RateLimit(loginLimit, middleware.ByIP), ctr.Token.Create)
+			tokenRouter.POST("/refresh", ctr.Token.Refresh)
+			tokenRouter.POST("/revoke", middleware.CheckRole(common.LevelUser), ctr.Token.Revoke)
+			tokenRouter.GET("/jwks", ctr.Token.JWKS)
+		}
+
+		keyRouter := apiRouter.Group("/keys", middleware.RequireScope("keys"), middleware.CheckRole(common.LevelUser))
+		{
+			keyRouter.GET("", ctr.APIKey.List)
+			keyRouter.POST("", ctr.APIKey.Create)
+			keyRouter.DELETE("/:id", ctr.APIKey.Revoke)
+		}
+
+		sessionRouter := apiRouter.Group("/sessions", middleware.CheckRole(common.LevelUser))
+		{
+			sessionRouter.GET("", ctr.ActiveSession.List)
+			sessionRouter.DELETE("/:sid", ctr.ActiveSession.Revoke)
+		}
+		apiRouter.DELETE("/users/:id/sessions", middleware.CheckRole(common.LevelAdmin), ctr.ActiveSession.RevokeAll)
+		apiRouter.POST("/users/:id/unlock", middleware.CheckRole(common.LevelAdmin), ctr.LoginGuard.Unlock)
+		apiRouter.GET("/users/:id/roles", middleware.RequirePermission("role:manage"), ctr.RBAC.UserRoles)
+		apiRouter.PUT("/users/:id/roles/:roleId", middleware.RequirePermission("role:manage"), ctr.RBAC.AssignRole)
+		apiRouter.DELETE("/users/:id/roles/:roleId", middleware.RequirePermission("role:manage"), ctr.RBAC.UnassignRole)
+
+		roleRouter := apiRouter.Group("/roles", middleware.RequirePermission("role:manage"))
+		{
+			roleRouter.GET("", ctr.RBAC.ListRoles)
+			roleRouter.POST("", ctr.RBAC.CreateRole)
+			roleRouter.PUT("/:id", ctr.RBAC.UpdateRole)
+			roleRouter.DELETE("/:id", ctr.RBAC.DeleteRole)
+		}
+		quotaRouter := apiRouter.Group("/quotas", middleware.RequirePermission("quota:manage"))
+		{
+			quotaRouter.GET("/:type/:id", ctr.Quota.SubjectUsage)
+			quotaRouter.PUT("/:type/:id/:resource", ctr.Quota.SetOverride)
+			quotaRouter.DELETE("/:type/:id/:resource", ctr.Quota.DeleteOverride)
+		}
+		apiRouter.GET("/policy/decisions", middleware.RequirePermission("policy:debug"), ctr.Policy.Decisions)
+
+		historyRouter := apiRouter.Group("/history", middleware.CheckRole(common.LevelAdmin))
+		{
+			historyRouter.GET("/:table/:id", ctr.History.List)
+			historyRouter.GET("/:table/:id/diff", ctr.History.Diff)
+			historyRouter.POST("/:table/:id/revert", ctr.History.Revert)
+		}
 	}
 }
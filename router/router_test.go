@@ -0,0 +1,46 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"template/common"
+	"template/controller"
+	"template/model"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+)
+
+// 使用 API Key 访问未声明授权范围的接口时应被拒绝，且不进入处理函数
+func TestInitRouter_APIKeyDeniedOnUnscopedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(sessions.Sessions("test-session", cookie.NewStore([]byte("secret"))))
+	r.Use(func(c *gin.Context) {
+		c.Set("current-user", &controller.UserSession{ID: 1, Level: common.LevelAdmin})
+		c.Set("api-key", &model.APIKey{Scopes: "resources", Level: common.LevelAdmin})
+	})
+	InitRouter(r)
+
+	routes := []struct{ method, path string }{
+		{"GET", "/api/user/me"},
+		{"PUT", "/api/user/email"},
+		{"PUT", "/api/user/password"},
+		{"POST", "/api/user/2fa/disable"},
+		{"GET", "/api/sessions"},
+		{"GET", "/api/keys"},
+		{"GET", "/api/history/resource/1"},
+	}
+	for _, route := range routes {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(route.method, route.path, nil))
+		var resp controller.Response
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if code := gin.ErrorType(resp.Code); resp.Success || code != common.AuthErr && code != common.LevelErr {
+			t.Errorf("%s %s: error code = %d, want AuthErr or LevelErr (%s)", route.method, route.path, resp.Code, w.Body.String())
+		}
+	}
+}
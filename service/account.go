@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"template/common"
+	"template/config"
+	"template/logger"
+	"template/model"
+	"template/pkg/password"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Account struct {
+}
+
+const (
+	passwordResetTTL = 30 * time.Minute
+	emailVerifyTTL   = 24 * time.Hour
+	// 同一用户同一用途的邮件最短发送间隔
+	userTokenInterval = time.Minute
+)
+
+type accountMail struct {
+	AppName  string
+	Username string
+	Link     string
+	Minutes  int
+	Hours    int
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func hashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetEmail 修改邮箱，新邮箱需要重新验证
+func (a *Account) SetEmail(ctx context.Context, userID int, email string) error {
+	email = normalizeEmail(email)
+	var count int64
+	if err := model.DB.Model(&model.User{}).Where("email = ? AND id <> ?", email, userID).Count(&count).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if count > 0 {
+		return common.ErrNew(errors.New("邮箱已被使用"), common.OpErr)
+	}
+	if err := model.DB.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"email":             email,
+		"email_verified_at": nil,
+	}).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return a.SendVerification(ctx, userID)
+}
+
+// SendVerification 向当前邮箱发送验证邮件
+func (a *Account) SendVerification(ctx context.Context, userID int) error {
+	user, err := (&User{}).Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == nil || *user.Email == "" {
+		return common.ErrNew(errors.New("未设置邮箱"), common.OpErr)
+	}
+	if user.EmailVerifiedAt != nil {
+		return common.ErrNew(errors.New("邮箱已验证"), common.OpErr)
+	}
+	sent, err := a.send(ctx, user, model.TokenEmailVerify, emailVerifyTTL, "/verify-email")
+	if err != nil {
+		return err
+	}
+	if !sent {
+		return common.ErrNew(errors.New("发送过于频繁，请稍后再试"), common.TooManyErr)
+	}
+	return nil
+}
+
+// VerifyEmail 使用验证邮件中的令牌确认邮箱
+func (a *Account) VerifyEmail(token string) error {
+	return model.DB.Transaction(func(tx *gorm.DB) error {
+		record, err := a.consume(tx, token, model.TokenEmailVerify)
+		if err != nil {
+			return err
+		}
+		// 发送后修改过邮箱时旧链接失效
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND email = ?", record.UserID, record.Email).
+			Update("email_verified_at", time.Now())
+		if result.Error != nil {
+			return common.ErrNew(result.Error, common.SysErr)
+		}
+		if result.RowsAffected == 0 {
+			return common.ErrNew(errors.New("链接无效或已过期"), common.AuthErr)
+		}
+		return nil
+	})
+}
+
+// RequestPasswordReset 向已验证的邮箱发送重置密码邮件，邮箱不存在时同样返回成功，避免泄露账号信息
+func (a *Account) RequestPasswordReset(ctx context.Context, email string) error {
+	var user model.User
+	err := model.DB.WithContext(ctx).Take(&user, "email = ? AND email_verified_at IS NOT NULL", normalizeEmail(email)).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	// 异步发送，邮箱存在与否的响应时间一致
+	go func() {
+		if _, err := a.send(context.WithoutCancel(ctx), &user, model.TokenPasswordReset, passwordResetTTL, "/reset-password"); err != nil {
+			logger.Errorf("send password reset to user %d: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// ResetPassword 使用重置邮件中的令牌设置新密码，该用户的其他重置链接及所有会话同时失效
+func (a *Account) ResetPassword(ctx context.Context, token, newPwd string) error {
+	hash, err := password.Hash(newPwd)
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+
+	var user model.User
+	err = model.DB.Transaction(func(tx *gorm.DB) error {
+		record, err := a.consume(tx, token, model.TokenPasswordReset)
+		if err != nil {
+			return err
+		}
+		if err := tx.Take(&user, record.UserID).Error; err != nil {
+			return common.ErrNew(errors.New("链接无效或已过期"), common.AuthErr)
+		}
+		if err := tx.Model(&user).Update("password", hash).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		if err := tx.Model(&model.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, model.TokenPasswordReset).
+			Update("used_at", time.Now()).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := (&ActiveSession{}).RevokeAll(ctx, int(user.ID)); err != nil {
+		return err
+	}
+	// 通过邮箱证明身份后解除登录锁定
+	g, err := guards()
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if err := g.account.Reset(ctx, accountKey(user.Username)); err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return nil
+}
+
+// send 生成一次性令牌并发送邮件，距上次发送不足 userTokenInterval 时不发送，sent 为 false
+func (a *Account) send(ctx context.Context, user *model.User, purpose string, ttl time.Duration, path string) (sent bool, err error) {
+	var count int64
+	if err := model.DB.Model(&model.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", user.ID, purpose, time.Now().Add(-userTokenInterval)).
+		Count(&count).Error; err != nil {
+		return false, common.ErrNew(err, common.SysErr)
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return false, common.ErrNew(err, common.SysErr)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	record := model.UserToken{
+		UserID:    int(user.ID),
+		Purpose:   purpose,
+		Hash:      hashUserToken(token),
+		Email:     *user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := model.DB.Create(&record).Error; err != nil {
+		return false, common.ErrNew(err, common.SysErr)
+	}
+
+	data := accountMail{
+		AppName:  config.Config.AppName,
+		Username: user.Username,
+		Link:     config.Config.PublicURL + path + "?token=" + url.QueryEscape(token),
+		Minutes:  int(ttl / time.Minute),
+		Hours:    int(ttl / time.Hour),
+	}
+	if err := sendMail(ctx, *user.Email, purpose, data); err != nil {
+		return false, common.ErrNew(err, common.SysErr)
+	}
+	return true, nil
+}
+
+// consume 校验并使用令牌，需在事务中调用
+func (a *Account) consume(tx *gorm.DB, token, purpose string) (*model.UserToken, error) {
+	var record model.UserToken
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Take(&record, "hash = ? AND purpose = ?", hashUserToken(token), purpose).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, common.ErrNew(errors.New("链接无效或已过期"), common.AuthErr)
+	}
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	now := time.Now()
+	if record.UsedAt != nil || !now.Before(record.ExpiresAt) {
+		return nil, common.ErrNew(errors.New("链接无效或已过期"), common.AuthErr)
+	}
+	if err := tx.Model(&record).Update("used_at", now).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &record, nil
+}
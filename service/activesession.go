@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"template/common"
+	"template/model"
+
+	"gorm.io/gorm"
+)
+
+type ActiveSession struct {
+}
+
+type ActiveSessionResponse struct {
+	model.ActiveSession
+	Current bool `json:"current"`
+}
+
+// 最后活跃时间的更新间隔，避免每个请求都写库
+const activeSessionTouchInterval = time.Minute
+
+// Register 登记新的登录会话，ttl 为会话剩余有效期，返回会话登记ID
+func (s *ActiveSession) Register(userID int, ip, userAgent string, ttl time.Duration) (string, error) {
+	session := model.ActiveSession{
+		UserID:     userID,
+		Device:     deviceName(userAgent),
+		IP:         ip,
+		UserAgent:  truncate(userAgent, 512),
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(ttl),
+	}
+	if err := model.DB.Create(&session).Error; err != nil {
+		return "", common.ErrNew(err, common.SysErr)
+	}
+	return session.ID, nil
+}
+
+// Touch 校验会话未被注销并更新最后活跃时间及过期时间，返回 false 表示会话已失效
+func (s *ActiveSession) Touch(id string, userID int, ip, userAgent string, ttl time.Duration) (bool, error) {
+	var session model.ActiveSession
+	err := model.DB.Take(&session, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, common.ErrNew(err, common.SysErr)
+	}
+	if session.UserID != userID || session.RevokedAt != nil {
+		return false, nil
+	}
+
+	if time.Since(session.LastSeenAt) > activeSessionTouchInterval || session.IP != ip {
+		if err := model.DB.Model(&session).Updates(map[string]any{
+			"last_seen_at": time.Now(),
+			"expires_at":   time.Now().Add(ttl),
+			"ip":           ip,
+			"user_agent":   truncate(userAgent, 512),
+		}).Error; err != nil {
+			return false, common.ErrNew(err, common.SysErr)
+		}
+	}
+	return true, nil
+}
+
+// List 列出用户未注销且未过期的会话，current 为当前请求的会话登记ID
+func (s *ActiveSession) List(ctx context.Context, userID int, current string) ([]ActiveSessionResponse, error) {
+	var sessions []model.ActiveSession
+	if err := model.DB.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+
+	resp := make([]ActiveSessionResponse, len(sessions))
+	for i, session := range sessions {
+		resp[i] = ActiveSessionResponse{ActiveSession: session, Current: session.ID == current}
+	}
+	return resp, nil
+}
+
+// Revoke 注销单个会话，只能注销自己的会话
+func (s *ActiveSession) Revoke(ctx context.Context, id string) error {
+	result := model.DB.WithContext(ctx).Model(&model.ActiveSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return common.ErrNew(result.Error, common.SysErr)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNew(errors.New("会话不存在"), common.NotFoundErr)
+	}
+	return nil
+}
+
+// End 用户登出时结束会话，会话不存在时忽略
+func (s *ActiveSession) End(id string) error {
+	if err := model.DB.Model(&model.ActiveSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return nil
+}
+
+// RevokeAll 注销用户的所有会话，用于禁用账号或修改密码后强制下线
+func (s *ActiveSession) RevokeAll(ctx context.Context, userID int) (int64, error) {
+	result := model.DB.WithContext(ctx).Model(&model.ActiveSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return 0, common.ErrNew(result.Error, common.SysErr)
+	}
+	return result.RowsAffected, nil
+}
+
+// deviceName 从 User-Agent 中粗略识别设备
+func deviceName(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	var device string
+	switch {
+	case strings.Contains(ua, "miniprogram"):
+		device = "WeChat Mini Program"
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"):
+		device = "iOS"
+	case strings.Contains(ua, "android"):
+		device = "Android"
+	case strings.Contains(ua, "windows"):
+		device = "Windows"
+	case strings.Contains(ua, "mac os"):
+		device = "macOS"
+	case strings.Contains(ua, "linux"):
+		device = "Linux"
+	default:
+		return "Unknown"
+	}
+	if strings.Contains(ua, "micromessenger") && device != "WeChat Mini Program" {
+		device += " WeChat"
+	}
+	return device
+}
+
+// truncate 截断为最多 n 个字符，与数据库按字符计算的列长度一致，不会切开多字节字符
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
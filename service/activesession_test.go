@@ -0,0 +1,26 @@
+package service
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	cases := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"Mozilla", 10, "Mozilla"},
+		{"Mozilla", 3, "Moz"},
+		{"微信浏览器", 2, "微信"},
+		{"a微信", 2, "a微"},
+		{"微信", 2, "微信"},
+	}
+	for _, tc := range cases {
+		got := truncate(tc.in, tc.n)
+		if got != tc.want || !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tc.in, tc.n, got, tc.want)
+		}
+	}
+}
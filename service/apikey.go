@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"template/common"
+	"template/model"
+	"template/pkg/ratelimit"
+
+	"gorm.io/gorm"
+)
+
+type APIKey struct {
+}
+
+// 密钥格式为 tzk_<前缀>_<随机串>，前缀明文保存用于查找
+const apiKeyPrefix = "tzk_"
+
+type APIKeyForm struct {
+	Name      string     `json:"name" binding:"required,max=64"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,required,max=64"`
+	Level     int        `json:"level" binding:"min=0"`
+	RateLimit int        `json:"rateLimit" binding:"min=0"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type APIKeyCreateResponse struct {
+	model.APIKey
+	// Key 完整密钥，只在创建时返回一次
+	Key string `json:"key"`
+}
+
+// Create 创建密钥，caller 为当前请求使用的密钥，不为 nil 时新密钥的授权范围不能超出 caller
+func (a *APIKey) Create(ctx context.Context, userID, userLevel int, caller *model.APIKey, form APIKeyForm) (*APIKeyCreateResponse, error) {
+	if caller != nil {
+		for _, scope := range form.Scopes {
+			if !caller.HasScope(scope) {
+				return nil, common.ErrNew(errors.New("授权范围超出当前 API Key: "+scope), common.LevelErr)
+			}
+		}
+	}
+	if form.Level == 0 || form.Level > userLevel {
+		form.Level = userLevel
+	}
+	if form.ExpiresAt != nil && form.ExpiresAt.Before(time.Now()) {
+		return nil, common.ErrNew(errors.New("过期时间应晚于当前时间"), common.ParamErr)
+	}
+
+	prefix := make([]byte, 4)
+	secret := make([]byte, 24)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(prefix) + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey := model.APIKey{
+		UserID:    userID,
+		Name:      form.Name,
+		Prefix:    hex.EncodeToString(prefix),
+		Hash:      hashAPIKey(key),
+		Scopes:    strings.Join(form.Scopes, " "),
+		Level:     form.Level,
+		RateLimit: form.RateLimit,
+		ExpiresAt: form.ExpiresAt,
+	}
+	if err := model.DB.WithContext(ctx).Create(&apiKey).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &APIKeyCreateResponse{APIKey: apiKey, Key: key}, nil
+}
+
+func (a *APIKey) List(ctx context.Context, userID int) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	if err := model.DB.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("id DESC").
+		Find(&keys).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return keys, nil
+}
+
+func (a *APIKey) Revoke(ctx context.Context, id int) error {
+	result := model.DB.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return common.ErrNew(result.Error, common.SysErr)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNew(errors.New("API Key 不存在"), common.NotFoundErr)
+	}
+	return nil
+}
+
+// Authenticate 校验密钥并按密钥的限流设置计数，返回密钥及所属用户
+func (a *APIKey) Authenticate(ctx context.Context, raw string) (*model.APIKey, *model.User, error) {
+	invalid := common.ErrNew(errors.New("API Key 无效"), common.AuthErr)
+	rest, ok := strings.CutPrefix(raw, apiKeyPrefix)
+	if !ok {
+		return nil, nil, invalid
+	}
+	prefix, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return nil, nil, invalid
+	}
+
+	var key model.APIKey
+	err := model.DB.WithContext(ctx).Take(&key, "prefix = ?", prefix).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, invalid
+	}
+	if err != nil {
+		return nil, nil, common.ErrNew(err, common.SysErr)
+	}
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashAPIKey(raw))) != 1 {
+		return nil, nil, invalid
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, nil, common.ErrNew(errors.New("API Key 已失效"), common.AuthErr)
+	}
+
+	if key.RateLimit > 0 {
+		result := (&RateLimit{}).Allow(ctx, "api-key:"+strconv.Itoa(int(key.ID)), ratelimit.Limit{
+			Algorithm: ratelimit.SlidingWindow,
+			Limit:     key.RateLimit,
+			Period:    time.Minute,
+		})
+		if !result.Allowed {
+			return nil, nil, common.ErrNew(fmt.Errorf("请在 %d 秒后重试", int(result.RetryAfter.Seconds())+1), common.TooManyErr)
+		}
+	}
+
+	var user model.User
+	if err := model.DB.Take(&user, key.UserID).Error; err != nil {
+		return nil, nil, invalid
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > time.Minute {
+		if err := model.DB.Model(&key).Update("last_used_at", now).Error; err != nil {
+			return nil, nil, common.ErrNew(err, common.SysErr)
+		}
+	}
+	return &key, &user, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"template/common"
+	"template/config"
+	"template/logger"
+	"template/model"
+	"template/pkg/captcha"
+)
+
+type Captcha struct {
+}
+
+type CaptchaResponse struct {
+	ID        string    `json:"id"`
+	Image     string    `json:"image"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// 字符验证码的长度
+const captchaLength = 5
+
+// captchaStore 按 APP_CAPTCHA_STORE 创建的答案存储
+var captchaStore = sync.OnceValues(func() (captcha.Store, error) {
+	switch config.Config.CaptchaStore {
+	case "memory":
+		return captcha.NewMemoryStore(), nil
+	case "sql":
+		s := captcha.NewGormStore(model.DB)
+		if !config.Config.AppProd {
+			if err := s.Migrate(); err != nil {
+				return nil, err
+			}
+		}
+		go func() {
+			for range time.Tick(10 * time.Minute) {
+				if err := s.GC(context.Background()); err != nil {
+					logger.Errorf("captcha gc error: %v", err)
+				}
+			}
+		}()
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown captcha store %q", config.Config.CaptchaStore)
+})
+
+// Create 生成验证码，返回 ID 及 data URI 形式的 PNG 图片，kind 为空时使用 APP_CAPTCHA_KIND
+func (s *Captcha) Create(ctx context.Context, kind string) (*CaptchaResponse, error) {
+	if kind == "" {
+		kind = config.Config.CaptchaKind
+	}
+	challenge, err := captcha.New(captcha.Kind(kind), captchaLength)
+	if err != nil {
+		return nil, common.ErrNew(errors.New("验证码类型错误"), common.ParamErr)
+	}
+	img, err := captcha.Render(challenge.Display, captcha.Options{})
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	id := base64.RawURLEncoding.EncodeToString(raw)
+	expiresAt := time.Now().Add(time.Duration(config.Config.CaptchaTTL) * time.Second)
+
+	store, err := captchaStore()
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	if err := store.Set(ctx, id, challenge.Answer, expiresAt); err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &CaptchaResponse{
+		ID:        id,
+		Image:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(img),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Verify 校验答案，无论正确与否验证码都会失效
+func (s *Captcha) Verify(ctx context.Context, id, answer string) error {
+	if id == "" || answer == "" {
+		return common.ErrNew(errors.New("请输入验证码"), common.ParamErr)
+	}
+	store, err := captchaStore()
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	expected, ok, err := store.Take(ctx, id)
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if !ok || subtle.ConstantTimeCompare([]byte(expected), []byte(captcha.Normalize(answer))) != 1 {
+		return common.ErrNew(errors.New("验证码错误或已过期"), common.ParamErr)
+	}
+	return nil
+}
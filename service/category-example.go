@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+
+	"template/common"
+	"template/model"
+
+	"gorm.io/gorm"
+)
+
+type Category struct {
+}
+
+func (s *Category) Tree() ([]*model.Tree[*model.Category], error) {
+	var categories []*model.Category
+	if err := model.DB.Order("depth, id").Find(&categories).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return model.BuildTree(categories), nil
+}
+
+func (s *Category) Create(name string, parentID model.ID) (*model.Category, error) {
+	category := model.Category{Name: name}
+	if err := model.TreeInsert(model.DB, &category, parentID); err != nil {
+		return nil, categoryErr(err)
+	}
+	return &category, nil
+}
+
+func (s *Category) Move(id, parentID model.ID) (*model.Category, error) {
+	var category model.Category
+	category.ID = id
+	if err := model.TreeMove(model.DB, &category, parentID); err != nil {
+		return nil, categoryErr(err)
+	}
+	if err := model.DB.Take(&category, id).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &category, nil
+}
+
+func (s *Category) Ancestors(id model.ID) ([]model.Category, error) {
+	var category model.Category
+	if err := model.DB.Take(&category, id).Error; err != nil {
+		return nil, categoryErr(err)
+	}
+	var ancestors []model.Category
+	if err := model.TreeAncestors(model.DB, &category, &ancestors); err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return ancestors, nil
+}
+
+func categoryErr(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return common.ErrNew(errors.New("分类不存在"), common.NotFoundErr)
+	case errors.Is(err, model.ErrTreeCycle):
+		return common.ErrNew(errors.New("不能移动到自身或其子分类下"), common.OpErr)
+	}
+	return common.ErrNew(err, common.SysErr)
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"template/common"
+	"template/model"
+
+	"gorm.io/gorm"
+)
+
+type History struct {
+}
+
+type HistoryListResponse struct {
+	Total int64           `json:"total"`
+	List  []model.History `json:"list"`
+}
+
+type HistoryDiffResponse struct {
+	From    int                          `json:"from"`
+	To      int                          `json:"to"`
+	Changes map[string]model.FieldChange `json:"changes"`
+}
+
+func (h *History) List(table, id string, pager common.PagerForm) (*HistoryListResponse, error) {
+	if _, ok := model.NewTracked(table); !ok {
+		return nil, common.ErrNew(errors.New("该数据未开启变更历史"), common.ParamErr)
+	}
+	query := model.DB.Model(&model.History{}).Where("record_table = ? AND record_id = ?", table, id)
+
+	var resp HistoryListResponse
+	if err := query.Count(&resp.Total).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	if err := query.Order("version DESC").Scopes(model.Paginate(pager)).Find(&resp.List).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &resp, nil
+}
+
+func (h *History) Diff(table, id string, from, to int) (*HistoryDiffResponse, error) {
+	if _, ok := model.NewTracked(table); !ok {
+		return nil, common.ErrNew(errors.New("该数据未开启变更历史"), common.ParamErr)
+	}
+	before, err := h.version(model.DB, table, id, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := h.version(model.DB, table, id, to)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := model.DiffSnapshots(before.Snapshot, after.Snapshot)
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &HistoryDiffResponse{From: from, To: to, Changes: changes}, nil
+}
+
+// Revert 将记录恢复为指定版本的快照，恢复操作本身会记录为一个新版本
+func (h *History) Revert(ctx context.Context, table, id string, version int) (any, error) {
+	record, ok := model.NewTracked(table)
+	if !ok {
+		return nil, common.ErrNew(errors.New("该数据未开启变更历史"), common.ParamErr)
+	}
+
+	err := model.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		target, err := h.version(tx, table, id, version)
+		if err != nil {
+			return err
+		}
+		if target.Action == model.HistoryDelete {
+			return common.ErrNew(errors.New("不能恢复到已删除的版本"), common.OpErr)
+		}
+
+		if err := json.Unmarshal(target.Snapshot, record); err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		// 已删除的记录一并恢复
+		if err := tx.Unscoped().Save(record).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (h *History) version(db *gorm.DB, table, id string, version int) (*model.History, error) {
+	var history model.History
+	err := db.Where("record_table = ? AND record_id = ? AND version = ?", table, id, version).Take(&history).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, common.ErrNew(errors.New("该版本不存在"), common.OpErr)
+	}
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &history, nil
+}
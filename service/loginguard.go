@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"template/common"
+	"template/config"
+	"template/logger"
+	"template/model"
+	"template/pkg/loginguard"
+
+	"gorm.io/gorm"
+)
+
+type LoginGuard struct {
+}
+
+// LoginAttempt 一次登录尝试，Username 为用户提交的用户名，不要求存在
+type LoginAttempt struct {
+	Username  string
+	Method    string
+	IP        string
+	UserAgent string
+	// UserID 已确定用户时填写，为 0 时按用户名查找，用于审计
+	UserID int
+
+	// reserved 表示 Check 已预先记为一次失败
+	reserved bool
+}
+
+const (
+	loginBaseDelay = time.Second
+	loginMaxDelay  = 30 * time.Second
+	// 两步验证码连续错误达到该次数后锁定
+	mfaMaxFailures = 5
+)
+
+type loginGuards struct {
+	account *loginguard.Guard
+	ip      *loginguard.Guard
+	mfa     *loginguard.Guard
+}
+
+var guards = sync.OnceValues(func() (*loginGuards, error) {
+	var store loginguard.Store
+	lock := time.Duration(config.Config.LoginLockDuration) * time.Second
+	switch config.Config.LoginGuardStore {
+	case "memory":
+		store = loginguard.NewMemoryStore()
+	case "sql":
+		s := loginguard.NewGormStore(model.DB)
+		if !config.Config.AppProd {
+			if err := s.Migrate(); err != nil {
+				return nil, err
+			}
+		}
+		go func() {
+			for range time.Tick(time.Hour) {
+				if err := s.GC(context.Background(), time.Now().Add(-lock)); err != nil {
+					logger.Errorf("login guard gc error: %v", err)
+				}
+			}
+		}()
+		store = s
+	default:
+		return nil, fmt.Errorf("unknown login guard store %q", config.Config.LoginGuardStore)
+	}
+
+	policy := loginguard.Policy{
+		Window:       lock,
+		BaseDelay:    loginBaseDelay,
+		MaxDelay:     loginMaxDelay,
+		MaxFailures:  config.Config.LoginMaxFailures,
+		LockDuration: lock,
+		CaptchaAfter: config.Config.LoginCaptchaAfter,
+	}
+	ipPolicy := policy
+	ipPolicy.MaxFailures = config.Config.LoginIPMaxFailures
+	// 同一出口IP下可能有很多用户，只对账号逐次增加等待时间
+	ipPolicy.BaseDelay = 0
+	mfaPolicy := loginguard.Policy{Window: lock, MaxFailures: mfaMaxFailures, LockDuration: lock}
+	return &loginGuards{
+		account: loginguard.New(store, policy),
+		ip:      loginguard.New(store, ipPolicy),
+		mfa:     loginguard.New(store, mfaPolicy),
+	}, nil
+})
+
+// 账号按提交的用户名计数，用户不存在时同样计数，不泄露用户名是否存在
+func accountKey(username string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func mfaKey(userID int) string {
+	return fmt.Sprintf("mfa:%d", userID)
+}
+
+// Check 判断是否允许本次登录并预先记为一次失败，并发的尝试不会同时通过，captcha 表示需要校验验证码
+// 通过后应调用 Fail、Succeed 或 Release 之一
+func (l *LoginGuard) Check(ctx context.Context, attempt *LoginAttempt) (captcha bool, err error) {
+	g, err := guards()
+	if err != nil {
+		return false, common.ErrNew(err, common.SysErr)
+	}
+	account, err := g.account.Attempt(ctx, accountKey(attempt.Username))
+	if err != nil {
+		return false, common.ErrNew(err, common.SysErr)
+	}
+	if !account.Allowed {
+		return false, lockedErr(account)
+	}
+	ip, err := g.ip.Attempt(ctx, ipKey(attempt.IP))
+	if err != nil {
+		return false, common.ErrNew(err, common.SysErr)
+	}
+	if !ip.Allowed {
+		if err := g.account.Release(ctx, accountKey(attempt.Username)); err != nil {
+			return false, common.ErrNew(err, common.SysErr)
+		}
+		return false, lockedErr(ip)
+	}
+	attempt.reserved = true
+	return account.Captcha || ip.Captcha, nil
+}
+
+// Release 撤销 Check 预先记录的失败，用于未能完成校验的尝试，如验证码错误或系统错误
+func (l *LoginGuard) Release(ctx context.Context, attempt *LoginAttempt) error {
+	if !attempt.reserved {
+		return nil
+	}
+	g, err := guards()
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if err := g.account.Release(ctx, accountKey(attempt.Username)); err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if err := g.ip.Release(ctx, ipKey(attempt.IP)); err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	attempt.reserved = false
+	return nil
+}
+
+// Fail 记录一次失败，Check 已预先计数时不再重复计数，达到上限时锁定并记录审计
+func (l *LoginGuard) Fail(ctx context.Context, attempt LoginAttempt) error {
+	g, err := guards()
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	// 已预先计数时只读取当前状态
+	next := (*loginguard.Guard).Fail
+	if attempt.reserved {
+		next = (*loginguard.Guard).Check
+	}
+	account, err := next(g.account, ctx, accountKey(attempt.Username))
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	ip, err := next(g.ip, ctx, ipKey(attempt.IP))
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+
+	userID := attempt.UserID
+	if userID == 0 {
+		if err := model.DB.WithContext(ctx).Model(&model.User{}).
+			Where("username = ?", attempt.Username).Limit(1).Pluck("id", &userID).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+	}
+	if err := l.audit(ctx, attempt, model.LoginFailed, userID); err != nil {
+		return err
+	}
+	if account.Locked || ip.Locked {
+		return l.audit(ctx, attempt, model.LoginLocked, userID)
+	}
+	return nil
+}
+
+// Succeed 登录成功，清除账号的失败记录，IP 的记录保留到窗口结束，只撤销本次预先记录的失败
+func (l *LoginGuard) Succeed(ctx context.Context, attempt LoginAttempt, userID int) error {
+	g, err := guards()
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if err := g.account.Reset(ctx, accountKey(attempt.Username)); err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if attempt.reserved {
+		if err := g.ip.Release(ctx, ipKey(attempt.IP)); err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+	}
+	return l.audit(ctx, attempt, model.LoginSucceeded, userID)
+}
+
+// Unlock 管理员解锁用户，ip 不为空时同时解锁该IP
+func (l *LoginGuard) Unlock(ctx context.Context, userID int, ip string) error {
+	var user model.User
+	err := model.DB.Take(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return common.ErrNew(errors.New("用户不存在"), common.NotFoundErr)
+	}
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+
+	g, err := guards()
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if err := g.account.Reset(ctx, accountKey(user.Username)); err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if ip != "" {
+		if err := g.ip.Reset(ctx, ipKey(ip)); err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+	}
+	return l.audit(ctx, LoginAttempt{Username: user.Username, Method: "admin", IP: ip}, model.LoginUnlocked, userID)
+}
+
+func (l *LoginGuard) audit(ctx context.Context, attempt LoginAttempt, event string, userID int) error {
+	actor, _ := model.ActorFrom(ctx)
+	record := model.LoginAudit{
+		UserID:    userID,
+		Username:  truncate(attempt.Username, 64),
+		Event:     event,
+		Method:    attempt.Method,
+		IP:        attempt.IP,
+		UserAgent: truncate(attempt.UserAgent, 512),
+		RequestID: actor.RequestID,
+		ActorID:   int(actor.UserID),
+	}
+	if err := model.DB.Create(&record).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return nil
+}
+
+func lockedErr(d loginguard.Decision) error {
+	return common.ErrNew(fmt.Errorf("尝试次数过多，请 %d 秒后再试", retrySeconds(d.RetryAfter)), common.AuthErr)
+}
+
+func retrySeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
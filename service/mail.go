@@ -0,0 +1,83 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"sync"
+	"text/template"
+
+	"template/config"
+	"template/pkg/mailer"
+)
+
+//go:embed templates/mail
+var mailTemplates embed.FS
+
+var mailTemplateCache sync.Map
+
+// Mailer 按 APP_MAIL_DRIVER 创建的邮件发送方式，生产环境只能使用 smtp，否则返回错误
+var Mailer = sync.OnceValues(func() (mailer.Mailer, error) {
+	if config.Config.AppProd && config.Config.MailDriver != "smtp" {
+		return nil, fmt.Errorf("APP_MAIL_DRIVER must be smtp in production, got %q", config.Config.MailDriver)
+	}
+	switch config.Config.MailDriver {
+	case "smtp":
+		if config.Config.SMTPHost == "" {
+			return nil, errors.New("APP_SMTP_HOST is required for the smtp mail driver")
+		}
+		return &mailer.SMTP{
+			Host:     config.Config.SMTPHost,
+			Port:     config.Config.SMTPPort,
+			Username: config.Config.SMTPUser,
+			Password: config.Config.SMTPPass,
+			From:     config.Config.MailFrom,
+		}, nil
+	case "file":
+		return &mailer.File{Dir: config.Config.MailDir, From: config.Config.MailFrom}, nil
+	case "memory":
+		return &mailer.Memory{}, nil
+	}
+	return nil, fmt.Errorf("unknown mail driver %q", config.Config.MailDriver)
+})
+
+// renderMail 按 APP_LANGUAGE 渲染邮件模板，没有对应语言时使用英文
+func renderMail(name string, data any) (subject, body string, err error) {
+	lang := config.Config.AppLanguage
+	if _, err := mailTemplates.Open("templates/mail/" + lang + "/" + name + ".tmpl"); err != nil {
+		lang = "en"
+	}
+	path := "templates/mail/" + lang + "/" + name + ".tmpl"
+	cached, ok := mailTemplateCache.Load(path)
+	if !ok {
+		t, err := template.ParseFS(mailTemplates, path)
+		if err != nil {
+			return "", "", err
+		}
+		cached, _ = mailTemplateCache.LoadOrStore(path, t)
+	}
+	t := cached.(*template.Template)
+
+	var s, b bytes.Buffer
+	if err := t.ExecuteTemplate(&s, "subject", data); err != nil {
+		return "", "", err
+	}
+	if err := t.ExecuteTemplate(&b, "body", data); err != nil {
+		return "", "", err
+	}
+	return s.String(), b.String(), nil
+}
+
+func sendMail(ctx context.Context, to, name string, data any) error {
+	m, err := Mailer()
+	if err != nil {
+		return err
+	}
+	subject, body, err := renderMail(name, data)
+	if err != nil {
+		return err
+	}
+	return m.Send(ctx, mailer.Message{To: []string{to}, Subject: subject, Body: body})
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"template/common"
+	"template/config"
+	"template/model"
+	"template/pkg/totp"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MFA struct {
+}
+
+type MFASetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+const (
+	// mfaSkew 允许前后各一个时间步的时钟误差
+	mfaSkew           = 1
+	recoveryCodeCount = 10
+)
+
+func (m *MFA) user(ctx context.Context, userID int) (*model.User, error) {
+	var user model.User
+	err := model.DB.WithContext(ctx).Take(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, common.ErrNew(errors.New("用户不存在"), common.NotFoundErr)
+	}
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &user, nil
+}
+
+// Setup 生成新的密钥，启用前需使用验证码确认
+func (m *MFA) Setup(ctx context.Context, userID int) (*MFASetupResponse, error) {
+	user, err := m.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, common.ErrNew(errors.New("已启用两步验证"), common.OpErr)
+	}
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	if err := model.DB.Model(user).Select("totp_secret", "totp_counter").
+		Updates(&model.User{TOTPSecret: secret}).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &MFASetupResponse{Secret: secret, URI: totp.URI(config.Config.MFAIssuer, user.Username, secret)}, nil
+}
+
+// QRCode 返回待启用密钥的二维码 PNG
+func (m *MFA) QRCode(ctx context.Context, userID int) ([]byte, error) {
+	user, err := m.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled || user.TOTPSecret == "" {
+		return nil, common.ErrNew(errors.New("请先生成两步验证密钥"), common.OpErr)
+	}
+	png, err := totp.QRCode(totp.URI(config.Config.MFAIssuer, user.Username, user.TOTPSecret), 256)
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return png, nil
+}
+
+// Enable 校验验证码后启用两步验证，返回只显示一次的恢复码
+func (m *MFA) Enable(ctx context.Context, userID int, code string) ([]string, error) {
+	user, err := m.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, common.ErrNew(errors.New("已启用两步验证"), common.OpErr)
+	}
+	if user.TOTPSecret == "" {
+		return nil, common.ErrNew(errors.New("请先生成两步验证密钥"), common.OpErr)
+	}
+	if err := m.verifyTOTP(user, code); err != nil {
+		return nil, err
+	}
+	if err := model.DB.Model(user).Update("totp_enabled", true).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return m.newRecoveryCodes(userID)
+}
+
+// Disable 校验验证码或恢复码后关闭两步验证
+func (m *MFA) Disable(ctx context.Context, userID int, code string) error {
+	if err := m.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return model.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Select("totp_secret", "totp_enabled", "totp_counter").
+			Updates(&model.User{}).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		return nil
+	})
+}
+
+// RecoveryCodes 校验验证码后重新生成恢复码，旧恢复码全部失效
+func (m *MFA) RecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	user, err := m.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, common.ErrNew(errors.New("未启用两步验证"), common.OpErr)
+	}
+	if err := m.verifyTOTP(user, code); err != nil {
+		return nil, err
+	}
+	return m.newRecoveryCodes(userID)
+}
+
+// Verify 校验验证码，也可使用未使用过的恢复码
+// 同一用户连续错误达到 mfaMaxFailures 次后锁定，计数与登录失败次数保存在同一存储中
+func (m *MFA) Verify(ctx context.Context, userID int, code string) error {
+	g, err := guards()
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	key := mfaKey(userID)
+	d, err := g.mfa.Attempt(ctx, key)
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if !d.Allowed {
+		return lockedErr(d)
+	}
+
+	err = m.verify(ctx, userID, code)
+	var ginErr *gin.Error
+	switch {
+	case err == nil:
+		if rerr := g.mfa.Reset(ctx, key); rerr != nil {
+			return common.ErrNew(rerr, common.SysErr)
+		}
+	case !errors.As(err, &ginErr) || ginErr.Type != common.AuthErr:
+		// 未能完成校验的尝试不计为失败
+		if rerr := g.mfa.Release(ctx, key); rerr != nil {
+			return common.ErrNew(rerr, common.SysErr)
+		}
+	}
+	return err
+}
+
+func (m *MFA) verify(ctx context.Context, userID int, code string) error {
+	user, err := m.user(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return common.ErrNew(errors.New("未启用两步验证"), common.OpErr)
+	}
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return m.verifyTOTP(user, code)
+	}
+
+	result := model.DB.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND hash = ? AND used_at IS NULL", userID, model.BlindIndex("recovery-code", normalizeRecoveryCode(code))).
+		Limit(1).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return common.ErrNew(result.Error, common.SysErr)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNew(errors.New("验证码错误"), common.AuthErr)
+	}
+	return nil
+}
+
+// verifyTOTP 校验验证码，同一时间步的验证码只能使用一次
+func (m *MFA) verifyTOTP(user *model.User, code string) error {
+	counter, ok := totp.Validate(user.TOTPSecret, code, time.Now(), mfaSkew)
+	if !ok {
+		return common.ErrNew(errors.New("验证码错误"), common.AuthErr)
+	}
+	result := model.DB.Model(&model.User{}).
+		Where("id = ? AND totp_counter < ?", user.ID, counter).
+		Update("totp_counter", counter)
+	if result.Error != nil {
+		return common.ErrNew(result.Error, common.SysErr)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNew(errors.New("验证码已使用，请等待下一个验证码"), common.AuthErr)
+	}
+	return nil
+}
+
+func (m *MFA) newRecoveryCodes(userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	records := make([]model.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, common.ErrNew(err, common.SysErr)
+		}
+		codes[i] = code
+		records[i] = model.RecoveryCode{UserID: userID, Hash: model.BlindIndex("recovery-code", normalizeRecoveryCode(code))}
+	}
+	err := model.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return codes, nil
+}
+
+// 恢复码使用不易混淆的字符，格式为 xxxxx-xxxxx
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = recoveryAlphabet[int(b[i])%len(recoveryAlphabet)]
+	}
+	return string(b[:5]) + "-" + string(b[5:]), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
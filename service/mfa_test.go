@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"template/common"
+	"template/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 两步验证的错误次数按用户在服务端计数，达到上限后不再校验验证码
+func TestMFAVerify_LockedAfterFailures(t *testing.T) {
+	fake := useFakeResourceDB(t)
+	saved := config.Config
+	t.Cleanup(func() { config.Config = saved })
+	config.Config.LoginGuardStore = "memory"
+	config.Config.LoginLockDuration = 900
+	g, err := guards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for range mfaMaxFailures {
+		if _, err := g.mfa.Fail(ctx, mfaKey(7)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = (&MFA{}).Verify(ctx, 7, "123456")
+	var ginErr *gin.Error
+	if !errors.As(err, &ginErr) || ginErr.Type != common.AuthErr || !strings.Contains(err.Error(), "尝试次数过多") {
+		t.Fatalf("expected lock error, got %v", err)
+	}
+	if len(fake.queries) != 0 {
+		t.Fatalf("locked user should not be checked, queries: %v", fake.queries)
+	}
+	if d, _ := g.mfa.Check(ctx, mfaKey(8)); !d.Allowed {
+		t.Fatalf("other users should not be affected: %+v", d)
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"template/common"
+	"template/config"
+	"template/model"
+	"template/pkg/oidc"
+
+	"gorm.io/gorm"
+)
+
+type OIDC struct {
+}
+
+// OIDCFlow 授权过程中保存在 session 的状态
+type OIDCFlow struct {
+	Provider string
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// 已完成发现的身份提供方，首次使用时初始化
+var oidcProviders sync.Map
+
+func (o *OIDC) provider(ctx context.Context, name string) (*oidc.Provider, error) {
+	if p, ok := oidcProviders.Load(name); ok {
+		return p.(*oidc.Provider), nil
+	}
+	for _, cfg := range config.Config.OIDCProviders {
+		if cfg.Name != name {
+			continue
+		}
+		p, err := oidc.Discover(ctx, oidc.Config{
+			Issuer:       cfg.Issuer,
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+		})
+		if err != nil {
+			return nil, common.ErrNew(err, common.SysErr)
+		}
+		oidcProviders.Store(name, p)
+		return p, nil
+	}
+	return nil, common.ErrNew(errors.New("身份提供方不存在"), common.NotFoundErr)
+}
+
+// Begin 开始授权，返回跳转地址及需保存在 session 中的状态
+func (o *OIDC) Begin(ctx context.Context, name string) (string, OIDCFlow, error) {
+	p, err := o.provider(ctx, name)
+	if err != nil {
+		return "", OIDCFlow{}, err
+	}
+	flow := OIDCFlow{
+		Provider: name,
+		State:    oidc.RandomString(),
+		Nonce:    oidc.RandomString(),
+		Verifier: oidc.RandomString(),
+	}
+	return p.AuthCodeURL(flow.State, flow.Nonce, flow.Verifier), flow, nil
+}
+
+// Callback 使用授权码换取并校验 ID 令牌，返回绑定的本地用户，首次登录时自动创建
+func (o *OIDC) Callback(ctx context.Context, flow OIDCFlow, code string) (*model.User, error) {
+	p, err := o.provider(ctx, flow.Provider)
+	if err != nil {
+		return nil, err
+	}
+	token, err := p.Exchange(ctx, code, flow.Verifier)
+	if err != nil {
+		return nil, common.ErrNew(err, common.AuthErr)
+	}
+	id, err := p.Verify(ctx, token.IDToken, flow.Nonce)
+	if err != nil {
+		return nil, common.ErrNew(err, common.AuthErr)
+	}
+
+	var user model.User
+	err = model.DB.Transaction(func(tx *gorm.DB) error {
+		var identity model.UserIdentity
+		err := tx.Take(&identity, "provider = ? AND subject = ?", flow.Provider, id.Subject).Error
+		if err == nil {
+			return tx.Take(&user, identity.UserID).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		base := id.PreferredUsername
+		if base == "" {
+			base, _, _ = strings.Cut(id.Email, "@")
+		}
+		username, err := availableUsername(tx, base)
+		if err != nil {
+			return err
+		}
+		// 外部账号不设置本地密码，无法使用密码登录
+		user = model.User{Username: username, Level: common.LevelUser}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		identity = model.UserIdentity{
+			UserID:   int(user.ID),
+			Provider: flow.Provider,
+			Subject:  id.Subject,
+			Email:    id.Email,
+		}
+		return tx.Create(&identity).Error
+	})
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &user, nil
+}
+
+// availableUsername 由 base 生成未被占用的用户名，只保留字母和数字
+func availableUsername(tx *gorm.DB, base string) (string, error) {
+	base = strings.Map(func(r rune) rune {
+		if r < 128 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, base)
+	if len(base) < 3 {
+		base = "user"
+	}
+	if len(base) > 24 {
+		base = base[:24]
+	}
+
+	username := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := tx.Model(&model.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return username, nil
+		}
+		n, _ := rand.Int(rand.Reader, big.NewInt(1000000))
+		username = fmt.Sprintf("%s%06d", base, n.Int64())
+	}
+	return "", errors.New("no available username")
+}
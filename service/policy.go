@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"template/common"
+	"template/logger"
+	"template/model"
+	"template/pkg/policy"
+)
+
+type Policy struct {
+}
+
+// 保留最近的拒绝决定数量，供排查
+const deniedDecisionsSize = 200
+
+var deniedDecisions struct {
+	sync.Mutex
+	list []policy.Decision
+}
+
+// Policies 策略引擎，新增资源类型的策略应在此处注册
+var Policies = sync.OnceValue(func() *policy.Engine {
+	e := policy.New()
+	e.Log = logDecision
+
+	// example
+	// begin
+	e.Register("resource", "*", "admin", policy.AllowLevel(common.LevelAdmin))
+	e.Register("resource", "*", "owner", policy.AllowOwner(func(r any) (int, bool) {
+		resource, ok := r.(*model.Resource)
+		if !ok {
+			return 0, false
+		}
+		return resource.UserID, true
+	}))
+	e.Register("resource", "*", "permission", policy.AllowPermission("resource"))
+	// end
+
+	return e
+})
+
+// logDecision 拒绝的决定记录日志并保留在内存中，允许的决定只在 debug 级别记录
+func logDecision(_ context.Context, d policy.Decision) {
+	data, _ := json.Marshal(d)
+	if d.Allowed {
+		logger.Debugf("policy allowed %s", data)
+		return
+	}
+	logger.Infof("policy denied %s", data)
+
+	deniedDecisions.Lock()
+	defer deniedDecisions.Unlock()
+	if len(deniedDecisions.list) >= deniedDecisionsSize {
+		deniedDecisions.list = deniedDecisions.list[1:]
+	}
+	deniedDecisions.list = append(deniedDecisions.list, d)
+}
+
+// Evaluate 以 ctx 中的操作人为主体执行策略，环境属性来自 policy.ContextWithEnv
+func (p *Policy) Evaluate(ctx context.Context, typ, action string, resource any) (policy.Decision, error) {
+	actor, _ := model.ActorFrom(ctx)
+	subject := policy.Subject{ID: int(actor.UserID), Level: actor.Level}
+	if actor.UserID != 0 {
+		permissions, err := (&RBAC{}).Permissions(ctx, int(actor.UserID), actor.Level)
+		if err != nil {
+			return policy.Decision{}, err
+		}
+		subject.Permissions = permissions
+	}
+
+	env := maps.Clone(policy.EnvFrom(ctx))
+	if env == nil {
+		env = map[string]any{}
+	}
+	env["time"] = time.Now()
+	if actor.RequestID != "" {
+		env["requestId"] = actor.RequestID
+	}
+
+	return Policies().Evaluate(ctx, policy.Request{
+		Subject:  subject,
+		Type:     typ,
+		Action:   action,
+		Resource: resource,
+		Env:      env,
+	}), nil
+}
+
+// Authorize 策略拒绝时返回 LevelErr，可在服务中修改数据前调用
+func (p *Policy) Authorize(ctx context.Context, typ, action string, resource any) error {
+	d, err := p.Evaluate(ctx, typ, action, resource)
+	if err != nil {
+		return err
+	}
+	if !d.Allowed {
+		return common.ErrNew(errors.New("权限不足"), common.LevelErr)
+	}
+	return nil
+}
+
+// Decisions 最近被拒绝的决定，新的在前，userID 为 0 时不按用户筛选
+func (p *Policy) Decisions(userID, limit int) []policy.Decision {
+	deniedDecisions.Lock()
+	list := slices.Clone(deniedDecisions.list)
+	deniedDecisions.Unlock()
+
+	result := make([]policy.Decision, 0, limit)
+	for _, d := range slices.Backward(list) {
+		if len(result) >= limit {
+			break
+		}
+		if userID == 0 || d.SubjectID == userID {
+			result = append(result, d)
+		}
+	}
+	return result
+}
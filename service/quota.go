@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"template/common"
+	"template/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Quota struct {
+}
+
+// QuotaPeriod 配额的统计周期
+type QuotaPeriod string
+
+const (
+	QuotaDaily   QuotaPeriod = "day"
+	QuotaMonthly QuotaPeriod = "month"
+	QuotaTotal   QuotaPeriod = "total" // 不重置，如存储空间
+)
+
+// QuotaDefinition 配额的统计周期及默认上限，Limit 为 -1 时不限制
+type QuotaDefinition struct {
+	Period QuotaPeriod
+	Limit  int64
+}
+
+// 配额名称
+const (
+	QuotaAPICalls     = "api-calls"
+	QuotaUploads      = "uploads"
+	QuotaStorageBytes = "storage-bytes"
+	QuotaSMSSends     = "sms-sends"
+)
+
+// quotaDefinitions 各配额的默认上限，新增配额应在此处注册
+var quotaDefinitions = map[string]QuotaDefinition{
+	QuotaAPICalls:     {Period: QuotaDaily, Limit: 10000},
+	QuotaUploads:      {Period: QuotaDaily, Limit: 100},
+	QuotaStorageBytes: {Period: QuotaTotal, Limit: 1 << 30},
+	QuotaSMSSends:     {Period: QuotaMonthly, Limit: 100},
+}
+
+// QuotaSubject 配额归属的对象
+type QuotaSubject struct {
+	Type string
+	ID   int
+}
+
+// UserQuota 用户的配额
+func UserQuota(userID int) QuotaSubject {
+	return QuotaSubject{Type: model.QuotaSubjectUser, ID: userID}
+}
+
+type QuotaUsageResponse struct {
+	Resource  string      `json:"resource"`
+	Period    QuotaPeriod `json:"period"`
+	Limit     int64       `json:"limit"`
+	Used      int64       `json:"used"`
+	Remaining int64       `json:"remaining"`
+	Override  bool        `json:"override"`
+	ResetAt   *time.Time  `json:"resetAt"`
+}
+
+// quotaPeriod 当前统计周期的标识及重置时间
+func quotaPeriod(period QuotaPeriod, now time.Time) (string, *time.Time) {
+	y, m, d := now.Date()
+	switch period {
+	case QuotaDaily:
+		reset := time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+		return "d:" + now.Format("2006-01-02"), &reset
+	case QuotaMonthly:
+		reset := time.Date(y, m+1, 1, 0, 0, 0, 0, now.Location())
+		return "m:" + now.Format("2006-01"), &reset
+	}
+	return "total", nil
+}
+
+func quotaDefinition(resource string) (QuotaDefinition, error) {
+	def, ok := quotaDefinitions[resource]
+	if !ok {
+		return def, common.ErrNew(errors.New("配额不存在"), common.NotFoundErr)
+	}
+	return def, nil
+}
+
+// limit 对象的配额上限，有管理员设置时优先使用
+func (q *Quota) limit(db *gorm.DB, subject QuotaSubject, resource string, def QuotaDefinition) (int64, bool, error) {
+	var override model.QuotaOverride
+	err := db.Take(&override, "subject_type = ? AND subject_id = ? AND resource = ?", subject.Type, subject.ID, resource).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return def.Limit, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return override.Limit, true, nil
+}
+
+// Consume 占用 n 个单位的配额，超过上限时不占用并返回 QuotaErr
+// 判断与累加在同一条 UPDATE 语句中完成，并发调用时不会超出上限
+func (q *Quota) Consume(ctx context.Context, subject QuotaSubject, resource string, n int64) error {
+	def, err := quotaDefinition(resource)
+	if err != nil {
+		return err
+	}
+	if n <= 0 {
+		return nil
+	}
+	db := model.DB.WithContext(ctx)
+	limit, _, err := q.limit(db, subject, resource, def)
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+
+	period, _ := quotaPeriod(def.Period, time.Now())
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.QuotaUsage{
+		SubjectType: subject.Type,
+		SubjectID:   subject.ID,
+		Resource:    resource,
+		Period:      period,
+	}).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+
+	update := db.Model(&model.QuotaUsage{}).
+		Where("subject_type = ? AND subject_id = ? AND resource = ? AND period = ?", subject.Type, subject.ID, resource, period)
+	if limit >= 0 {
+		update = update.Where("used + ? <= ?", n, limit)
+	}
+	result := update.Update("used", gorm.Expr("used + ?", n))
+	if result.Error != nil {
+		return common.ErrNew(result.Error, common.SysErr)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNew(errors.New("配额已用完: "+resource), common.QuotaErr)
+	}
+	return nil
+}
+
+// Release 归还 n 个单位的配额，用于删除文件释放存储空间或操作失败后退回
+func (q *Quota) Release(ctx context.Context, subject QuotaSubject, resource string, n int64) error {
+	def, err := quotaDefinition(resource)
+	if err != nil {
+		return err
+	}
+	if n <= 0 {
+		return nil
+	}
+	period, _ := quotaPeriod(def.Period, time.Now())
+	if err := model.DB.WithContext(ctx).Model(&model.QuotaUsage{}).
+		Where("subject_type = ? AND subject_id = ? AND resource = ? AND period = ?", subject.Type, subject.ID, resource, period).
+		Update("used", gorm.Expr("GREATEST(used - ?, 0)", n)).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return nil
+}
+
+// Usage 对象在当前周期内各配额的用量
+func (q *Quota) Usage(ctx context.Context, subject QuotaSubject) ([]QuotaUsageResponse, error) {
+	db := model.DB.WithContext(ctx)
+	now := time.Now()
+
+	var overrides []model.QuotaOverride
+	if err := db.Find(&overrides, "subject_type = ? AND subject_id = ?", subject.Type, subject.ID).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	var usages []model.QuotaUsage
+	if err := db.Find(&usages, "subject_type = ? AND subject_id = ?", subject.Type, subject.ID).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+
+	resources := make([]string, 0, len(quotaDefinitions))
+	for resource := range quotaDefinitions {
+		resources = append(resources, resource)
+	}
+	slices.Sort(resources)
+
+	resp := make([]QuotaUsageResponse, 0, len(resources))
+	for _, resource := range resources {
+		def := quotaDefinitions[resource]
+		period, resetAt := quotaPeriod(def.Period, now)
+		item := QuotaUsageResponse{Resource: resource, Period: def.Period, Limit: def.Limit, ResetAt: resetAt}
+		for _, o := range overrides {
+			if o.Resource == resource {
+				item.Limit, item.Override = o.Limit, true
+			}
+		}
+		for _, u := range usages {
+			if u.Resource == resource && u.Period == period {
+				item.Used = u.Used
+			}
+		}
+		item.Remaining = -1
+		if item.Limit >= 0 {
+			item.Remaining = max(item.Limit-item.Used, 0)
+		}
+		resp = append(resp, item)
+	}
+	return resp, nil
+}
+
+// SetOverride 为对象单独设置配额上限，limit 为 -1 时不限制
+func (q *Quota) SetOverride(ctx context.Context, subject QuotaSubject, resource string, limit int64, actorID int) error {
+	if _, err := quotaDefinition(resource); err != nil {
+		return err
+	}
+	if limit < -1 {
+		return common.ErrNew(errors.New("配额上限不能小于 -1"), common.ParamErr)
+	}
+	override := model.QuotaOverride{
+		SubjectType: subject.Type,
+		SubjectID:   subject.ID,
+		Resource:    resource,
+		Limit:       limit,
+		ActorID:     actorID,
+	}
+	if err := model.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"quota_limit", "actor_id", "updated_at"}),
+	}).Create(&override).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return nil
+}
+
+// DeleteOverride 删除单独设置的上限，恢复默认值
+func (q *Quota) DeleteOverride(ctx context.Context, subject QuotaSubject, resource string) error {
+	if _, err := quotaDefinition(resource); err != nil {
+		return err
+	}
+	if err := model.DB.WithContext(ctx).Unscoped().
+		Where("subject_type = ? AND subject_id = ? AND resource = ?", subject.Type, subject.ID, resource).
+		Delete(&model.QuotaOverride{}).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"template/config"
+	"template/logger"
+	"template/model"
+	"template/pkg/ratelimit"
+)
+
+type RateLimit struct {
+}
+
+// rateLimitStore 按 APP_RATELIMIT_STORE 创建的计数存储
+var rateLimitStore = sync.OnceValues(func() (ratelimit.Store, error) {
+	switch config.Config.RateLimitStore {
+	case "memory":
+		return ratelimit.NewSharded(64), nil
+	case "sql":
+		s := ratelimit.NewGormStore(model.DB)
+		if !config.Config.AppProd {
+			if err := s.Migrate(); err != nil {
+				return nil, err
+			}
+		}
+		go func() {
+			for range time.Tick(10 * time.Minute) {
+				if err := s.GC(context.Background()); err != nil {
+					logger.Errorf("rate limit gc error: %v", err)
+				}
+			}
+		}()
+		return s, nil
+	case "redis":
+		client, err := model.Redis()
+		if err != nil {
+			return nil, err
+		}
+		return ratelimit.NewRedis(client, "tz-ratelimit:"), nil
+	}
+	return nil, fmt.Errorf("unknown rate limit store %q", config.Config.RateLimitStore)
+})
+
+// Allow 判断 key 是否超过限流，存储不可用时记录日志并放行，避免限流故障导致接口不可用
+func (r *RateLimit) Allow(ctx context.Context, key string, limit ratelimit.Limit) ratelimit.Result {
+	store, err := rateLimitStore()
+	if err == nil {
+		var result ratelimit.Result
+		if result, err = store.Allow(ctx, key, limit); err == nil {
+			return result
+		}
+	}
+	logger.Errorf("rate limit %s: %v", key, err)
+	return ratelimit.Result{Allowed: true, Limit: limit.Limit, Remaining: limit.Limit}
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"template/common"
+	"template/config"
+	"template/model"
+	"template/pkg/permission"
+
+	"gorm.io/gorm"
+)
+
+type RBAC struct {
+}
+
+type RoleForm struct {
+	Name        string   `json:"name" binding:"required,max=64"`
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions" binding:"max=256,dive,max=128"`
+}
+
+type permissionEntry struct {
+	set        permission.Set
+	generation uint64
+	expiresAt  time.Time
+}
+
+var (
+	// permissionCache 按用户缓存权限，修改角色或分配角色时递增 permissionGeneration 使全部缓存失效
+	// 查询前读取 permissionGeneration，修改期间并发查询得到的旧权限不会被使用
+	permissionCache      sync.Map
+	permissionGeneration atomic.Uint64
+)
+
+// Permissions 用户拥有的权限，等级达到管理员的用户拥有全部权限
+func (r *RBAC) Permissions(ctx context.Context, userID, level int) (permission.Set, error) {
+	if level >= common.LevelAdmin {
+		return permission.Set{"*"}, nil
+	}
+	generation := permissionGeneration.Load()
+	if v, ok := permissionCache.Load(userID); ok {
+		entry := v.(permissionEntry)
+		if entry.generation == generation && time.Now().Before(entry.expiresAt) {
+			return entry.set, nil
+		}
+	}
+
+	set := permission.Set{}
+	if err := model.DB.WithContext(ctx).Model(&model.RolePermission{}).
+		Joins("JOIN user_role ON user_role.role_id = role_permission.role_id AND user_role.deleted_at IS NULL").
+		Where("user_role.user_id = ?", userID).
+		Distinct().Pluck("role_permission.permission", &set).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	permissionCache.Store(userID, permissionEntry{
+		set:        set,
+		generation: generation,
+		expiresAt:  time.Now().Add(time.Duration(config.Config.RBACCacheTTL) * time.Second),
+	})
+	return set, nil
+}
+
+// HasPermission 用户是否拥有 required 权限
+func (r *RBAC) HasPermission(ctx context.Context, userID, level int, required string) (bool, error) {
+	set, err := r.Permissions(ctx, userID, level)
+	if err != nil {
+		return false, err
+	}
+	return set.Has(required), nil
+}
+
+func (r *RBAC) ListRoles() ([]model.Role, error) {
+	var roles []model.Role
+	if err := model.DB.Order("id").Find(&roles).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	if err := loadRolePermissions(model.DB, roles); err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return roles, nil
+}
+
+func (r *RBAC) CreateRole(form RoleForm) (*model.Role, error) {
+	permissions, err := normalizePermissions(form.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	role := model.Role{Name: form.Name, Description: form.Description}
+	err = model.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkRoleName(tx, form.Name, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(&role).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		return setRolePermissions(tx, role.ID, permissions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = permissions
+	return &role, nil
+}
+
+func (r *RBAC) UpdateRole(id model.ID, form RoleForm) (*model.Role, error) {
+	permissions, err := normalizePermissions(form.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	var role model.Role
+	err = model.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Take(&role, id).Error; err != nil {
+			return roleErr(err)
+		}
+		if err := checkRoleName(tx, form.Name, id); err != nil {
+			return err
+		}
+		if err := tx.Model(&role).Updates(map[string]any{"name": form.Name, "description": form.Description}).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		return setRolePermissions(tx, id, permissions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	permissionGeneration.Add(1)
+	role.Permissions = permissions
+	return &role, nil
+}
+
+// DeleteRole 删除角色，同时解除所有用户与该角色的关联
+func (r *RBAC) DeleteRole(id model.ID) error {
+	err := model.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Delete(&model.Role{}, id)
+		if result.Error != nil {
+			return common.ErrNew(result.Error, common.SysErr)
+		}
+		if result.RowsAffected == 0 {
+			return roleErr(gorm.ErrRecordNotFound)
+		}
+		if err := tx.Unscoped().Where("role_id = ?", id).Delete(&model.UserRole{}).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		if err := tx.Unscoped().Where("role_id = ?", id).Delete(&model.RolePermission{}).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	permissionGeneration.Add(1)
+	return nil
+}
+
+// UserRoles 用户拥有的角色
+func (r *RBAC) UserRoles(userID int) ([]model.Role, error) {
+	var roles []model.Role
+	if err := model.DB.
+		Joins("JOIN user_role ON user_role.role_id = role.id AND user_role.deleted_at IS NULL").
+		Where("user_role.user_id = ?", userID).
+		Order("role.id").Find(&roles).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	if err := loadRolePermissions(model.DB, roles); err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return roles, nil
+}
+
+// AssignRole 为用户分配角色，已拥有时不做任何事
+func (r *RBAC) AssignRole(userID int, roleID model.ID) error {
+	err := model.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Take(&model.User{}, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return common.ErrNew(errors.New("用户不存在"), common.NotFoundErr)
+			}
+			return common.ErrNew(err, common.SysErr)
+		}
+		if err := tx.Take(&model.Role{}, roleID).Error; err != nil {
+			return roleErr(err)
+		}
+		var count int64
+		if err := tx.Model(&model.UserRole{}).Where("user_id = ? AND role_id = ?", userID, roleID).Count(&count).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		if count > 0 {
+			return nil
+		}
+		if err := tx.Create(&model.UserRole{UserID: userID, RoleID: roleID}).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	permissionGeneration.Add(1)
+	return nil
+}
+
+// UnassignRole 移除用户的角色
+func (r *RBAC) UnassignRole(userID int, roleID model.ID) error {
+	if err := model.DB.Unscoped().
+		Where("user_id = ? AND role_id = ?", userID, roleID).
+		Delete(&model.UserRole{}).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	permissionGeneration.Add(1)
+	return nil
+}
+
+// normalizePermissions 校验权限格式并去重
+func normalizePermissions(permissions []string) ([]string, error) {
+	result := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		if err := permission.Validate(p); err != nil {
+			return nil, common.ErrNew(errors.New("权限格式错误: "+p), common.ParamErr)
+		}
+		if !slices.Contains(result, p) {
+			result = append(result, p)
+		}
+	}
+	slices.Sort(result)
+	return result, nil
+}
+
+func checkRoleName(tx *gorm.DB, name string, exclude model.ID) error {
+	var count int64
+	if err := tx.Model(&model.Role{}).Where("name = ? AND id <> ?", name, exclude).Count(&count).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if count > 0 {
+		return common.ErrNew(errors.New("角色名已存在"), common.OpErr)
+	}
+	return nil
+}
+
+// setRolePermissions 以 permissions 替换角色的全部权限
+func setRolePermissions(tx *gorm.DB, roleID model.ID, permissions []string) error {
+	if err := tx.Unscoped().Where("role_id = ?", roleID).Delete(&model.RolePermission{}).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if len(permissions) == 0 {
+		return nil
+	}
+	records := make([]model.RolePermission, len(permissions))
+	for i, p := range permissions {
+		records[i] = model.RolePermission{RoleID: roleID, Permission: p}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return nil
+}
+
+func loadRolePermissions(tx *gorm.DB, roles []model.Role) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	ids := make([]model.ID, len(roles))
+	for i, role := range roles {
+		ids[i] = role.ID
+	}
+	var records []model.RolePermission
+	if err := tx.Where("role_id IN ?", ids).Order("permission").Find(&records).Error; err != nil {
+		return err
+	}
+	byRole := make(map[model.ID][]string, len(roles))
+	for _, record := range records {
+		byRole[record.RoleID] = append(byRole[record.RoleID], record.Permission)
+	}
+	for i := range roles {
+		roles[i].Permissions = byRole[roles[i].ID]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = []string{}
+		}
+	}
+	return nil
+}
+
+func roleErr(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return common.ErrNew(errors.New("角色不存在"), common.NotFoundErr)
+	}
+	return common.ErrNew(err, common.SysErr)
+}
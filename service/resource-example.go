@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"template/common"
+	"template/model"
+
+	"gorm.io/gorm"
+)
+
+type Resource struct {
+}
+
+type ResourceListResponse struct {
+	Total int64            `json:"total"`
+	List  []model.Resource `json:"list"`
+}
+
+// 数据访问均通过带操作人的 ctx 进行，查询结果自动限定为当前用户的记录
+
+func (r *Resource) List(ctx context.Context, pager common.PagerForm) (*ResourceListResponse, error) {
+	db := model.DB.WithContext(ctx).Model(&model.Resource{})
+
+	var resp ResourceListResponse
+	if err := db.Count(&resp.Total).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	if err := db.Order("id DESC").Scopes(model.Paginate(pager)).Find(&resp.List).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &resp, nil
+}
+
+func (r *Resource) Get(ctx context.Context, id int) (*model.Resource, error) {
+	var resource model.Resource
+	err := model.DB.WithContext(ctx).Take(&resource, id).Error
+	// 其他用户的记录与不存在的记录返回相同的错误
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, common.ErrNew(errors.New("资源不存在"), common.NotFoundErr)
+	}
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &resource, nil
+}
+
+func (r *Resource) Create(ctx context.Context, userID int, name, url string) (*model.Resource, error) {
+	resource := model.Resource{
+		UserID: userID,
+		Name:   name,
+		URL:    url,
+	}
+	if err := model.DB.WithContext(ctx).Create(&resource).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &resource, nil
+}
+
+func (r *Resource) Update(ctx context.Context, id int, name, url string) (*model.Resource, error) {
+	resource, err := r.authorize(ctx, id, "update")
+	if err != nil {
+		return nil, err
+	}
+	if err := model.DB.WithContext(ctx).Scopes(model.SkipOwnership).Model(resource).
+		Updates(model.Resource{Name: name, URL: url}).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return resource, nil
+}
+
+func (r *Resource) Delete(ctx context.Context, id int) error {
+	resource, err := r.authorize(ctx, id, "delete")
+	if err != nil {
+		return err
+	}
+	if err := model.DB.WithContext(ctx).Scopes(model.SkipOwnership).Delete(resource).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return nil
+}
+
+// authorize 不限定归属读取记录，由策略判断是否允许 action 操作，如拥有 resource:update 权限的其他用户
+// 不允许时与不存在的记录返回相同的错误
+func (r *Resource) authorize(ctx context.Context, id int, action string) (*model.Resource, error) {
+	var resource model.Resource
+	err := model.DB.WithContext(ctx).Scopes(model.SkipOwnership).Take(&resource, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, common.ErrNew(errors.New("资源不存在"), common.NotFoundErr)
+	}
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	d, err := (&Policy{}).Evaluate(ctx, "resource", action, &resource)
+	if err != nil {
+		return nil, err
+	}
+	if !d.Allowed {
+		return nil, common.ErrNew(errors.New("资源不存在"), common.NotFoundErr)
+	}
+	return &resource, nil
+}
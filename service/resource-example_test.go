@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"template/common"
+	"template/model"
+	"template/pkg/permission"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// fakeResourceDB 只有一条属于用户 2 的 resource 记录，查询带归属条件时查不到，记录执行过的语句
+type fakeResourceDB struct {
+	mu      sync.Mutex
+	queries []string
+	// execErr 不为空时决定写入语句返回的错误
+	execErr func(query string) error
+}
+
+func (d *fakeResourceDB) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+func (d *fakeResourceDB) log(query string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = append(d.queries, query)
+}
+
+type fakeConn struct{ db *fakeResourceDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeResourceDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.db.log(s.query)
+	if s.db.execErr != nil {
+		if err := s.db.execErr(s.query); err != nil {
+			return nil, err
+		}
+	}
+	return fakeResult{}, nil
+}
+
+// fakeResult 每条写入语句影响一行，插入时返回的自增主键为 1
+type fakeResult struct{}
+
+func (fakeResult) LastInsertId() (int64, error) { return 1, nil }
+func (fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.db.log(s.query)
+	rows := &fakeRows{columns: []string{"id", "user_id", "name", "url", "created_at", "updated_at", "deleted_at"}}
+	if strings.Contains(s.query, "FROM `resource`") && !strings.Contains(s.query, "`user_id` =") {
+		now := time.Now()
+		rows.values = [][]driver.Value{{int64(3), int64(2), "name", "https://example.com", now, now, nil}}
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func useFakeResourceDB(t *testing.T) *fakeResourceDB {
+	fake := &fakeResourceDB{}
+	name := "fake-resource-" + t.Name()
+	sql.Register(name, fake)
+	conn, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := model.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := model.DB
+	model.DB = db
+	t.Cleanup(func() { model.DB = old })
+	return fake
+}
+
+// 拥有 resource:update 权限的其他用户可以修改，没有权限的其他用户与记录不存在时相同
+func TestResourceUpdate_PermissionOverridesOwnership(t *testing.T) {
+	fake := useFakeResourceDB(t)
+	grant := func(userID int, perms ...string) {
+		permissionCache.Store(userID, permissionEntry{
+			set:        permission.Set(perms),
+			generation: permissionGeneration.Load(),
+			expiresAt:  time.Now().Add(time.Hour),
+		})
+	}
+	grant(7, "resource:update")
+	grant(8)
+	actor := func(id int) context.Context {
+		return model.ContextWithActor(context.Background(), model.Actor{UserID: model.ID(id), Level: common.LevelUser})
+	}
+
+	resource, err := (&Resource{}).Update(actor(7), 3, "renamed", "https://example.com/renamed")
+	if err != nil {
+		t.Fatalf("user with resource:update should be allowed: %v", err)
+	}
+	if resource.UserID != 2 {
+		t.Fatalf("unexpected resource %+v", resource)
+	}
+	updated := false
+	for _, q := range fake.queries {
+		if strings.HasPrefix(q, "UPDATE `resource`") {
+			updated = true
+			if strings.Contains(q, "`user_id` =") {
+				t.Errorf("update should not be scoped to the actor: %s", q)
+			}
+		}
+	}
+	if !updated {
+		t.Fatalf("resource was not updated: %v", fake.queries)
+	}
+
+	_, err = (&Resource{}).Update(actor(8), 3, "renamed", "https://example.com/renamed")
+	var ginErr *gin.Error
+	if !errors.As(err, &ginErr) || ginErr.Type != common.NotFoundErr {
+		t.Fatalf("user without permission should get not found, got %v", err)
+	}
+}
+
+// 变更历史在修改前加锁读取快照，并发修改时记录的变更与实际一致
+func TestResourceUpdate_HistoryLocksSnapshot(t *testing.T) {
+	fake := useFakeResourceDB(t)
+	permissionCache.Store(7, permissionEntry{
+		set:        permission.Set([]string{"resource:update"}),
+		generation: permissionGeneration.Load(),
+		expiresAt:  time.Now().Add(time.Hour),
+	})
+	ctx := model.ContextWithActor(context.Background(), model.Actor{UserID: 7, Level: common.LevelUser})
+	if _, err := (&Resource{}).Update(ctx, 3, "renamed", "https://example.com/renamed"); err != nil {
+		t.Fatal(err)
+	}
+
+	locked := false
+	for _, q := range fake.queries {
+		if strings.HasPrefix(q, "UPDATE `resource`") {
+			break
+		}
+		if strings.HasPrefix(q, "SELECT * FROM `resource`") && strings.HasSuffix(q, "FOR UPDATE") {
+			locked = true
+		}
+	}
+	if !locked {
+		t.Fatalf("snapshot before update should be read with a lock: %v", fake.queries)
+	}
+}
@@ -2,6 +2,24 @@ package service
 
 type Service struct {
 	Hello
+	History
+	Resource
+	Category
+	ActiveSession
+	User
+	OIDC
+	Token
+	APIKey
+	MFA
+	LoginGuard
+	Account
+	SMS
+	Wechat
+	RBAC
+	Policy
+	Captcha
+	RateLimit
+	Quota
 }
 
 func New() *Service {
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"template/config"
+	"template/logger"
+	"template/model"
+	"template/pkg/sessionstore"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+)
+
+// SessionStore 按 APP_SESSION_STORE 创建的 session 存储，sql 存储使用 model.DB，应在 model.Init 之后调用
+var SessionStore = sync.OnceValues(func() (sessions.Store, error) {
+	secret := []byte(config.Config.AppSecret)
+	var backend sessionstore.Backend
+	switch config.Config.SessionStore {
+	case "cookie":
+		return cookie.NewStore(secret), nil
+	case "memory":
+		backend = sessionstore.NewMemoryBackend()
+	case "sql":
+		b := sessionstore.NewGormBackend(model.DB)
+		if !config.Config.AppProd {
+			if err := b.Migrate(); err != nil {
+				return nil, err
+			}
+		}
+		backend = b
+	case "redis":
+		client, err := model.Redis()
+		if err != nil {
+			return nil, err
+		}
+		backend = sessionstore.NewRedisBackend(client, "tz-sessions:")
+	default:
+		return nil, fmt.Errorf("unknown session store %q", config.Config.SessionStore)
+	}
+
+	store := sessionstore.New(backend, secret)
+	store.StartGC(10*time.Minute, func(err error) {
+		logger.Errorf("session gc error: %v", err)
+	})
+	return store, nil
+})
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"template/common"
+	"template/config"
+	"template/logger"
+	"template/model"
+	"template/pkg/ratelimit"
+	"template/pkg/sms"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SMS struct {
+}
+
+// 验证码错误达到该次数后失效
+const smsMaxAttempts = 5
+
+// SMSProvider 按 APP_SMS_PROVIDER 创建的短信服务商，接入新服务商时应在此处注册
+var SMSProvider = sync.OnceValues(func() (sms.Provider, error) {
+	if config.Config.AppProd && config.Config.SMSProvider == "log" {
+		return nil, errors.New("APP_SMS_PROVIDER log is not allowed in production")
+	}
+	switch config.Config.SMSProvider {
+	case "log":
+		return &sms.Log{Logf: logger.Infof}, nil
+	}
+	return nil, fmt.Errorf("unknown sms provider %q", config.Config.SMSProvider)
+})
+
+func phoneIndex(phone string) string {
+	return model.BlindIndex("phone", phone)
+}
+
+// SendCode 发送验证码，按手机号限制发送间隔及每日次数，按IP限制每小时次数
+func (s *SMS) SendCode(ctx context.Context, phone, purpose, ip string) error {
+	phone, err := sms.NormalizePhone(phone)
+	if err != nil {
+		return common.ErrNew(errors.New("手机号格式错误"), common.ParamErr)
+	}
+	index := phoneIndex(phone)
+	now := time.Now()
+
+	result := (&RateLimit{}).Allow(ctx, "sms-ip:"+ip, ratelimit.Limit{
+		Algorithm: ratelimit.SlidingWindow,
+		Limit:     config.Config.SMSIPHourlyLimit,
+		Period:    time.Hour,
+	})
+	if !result.Allowed {
+		return common.ErrNew(errors.New("发送过于频繁，请稍后再试"), common.TooManyErr)
+	}
+	if err := reserveSMSSend(ctx, index, now); err != nil {
+		return err
+	}
+	// 已登录用户发送时计入其短信配额，发送失败时退回
+	var quota *QuotaSubject
+	if actor, ok := model.ActorFrom(ctx); ok && actor.UserID != 0 {
+		subject := UserQuota(int(actor.UserID))
+		if err := (&Quota{}).Consume(ctx, subject, QuotaSMSSends, 1); err != nil {
+			return err
+		}
+		quota = &subject
+	}
+
+	if err := s.deliver(ctx, phone, purpose, ip, index, now); err != nil {
+		if quota != nil {
+			if rerr := (&Quota{}).Release(ctx, *quota, QuotaSMSSends, 1); rerr != nil {
+				logger.Errorf("release sms quota of %s %d: %v", quota.Type, quota.ID, rerr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// deliver 生成验证码并发送
+func (s *SMS) deliver(ctx context.Context, phone, purpose, ip, index string, now time.Time) error {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	ttl := time.Duration(config.Config.SMSCodeTTL) * time.Second
+	record := model.SMSCode{
+		PhoneIndex: index,
+		Purpose:    purpose,
+		Hash:       model.BlindIndex("sms-code", phone+":"+code),
+		IP:         ip,
+		ExpiresAt:  now.Add(ttl),
+	}
+	if err := model.DB.Create(&record).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+
+	provider, err := SMSProvider()
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	msg := sms.Message{
+		Phone:    phone,
+		Template: purpose,
+		Params:   map[string]string{"code": code, "minutes": strconv.Itoa(int(ttl / time.Minute))},
+	}
+	if err := provider.Send(ctx, msg); err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return nil
+}
+
+// reserveSMSSend 检查发送间隔及每日次数并记录本次发送，判断与累加在同一条 UPDATE 语句中完成，并发请求只有一个能通过
+func reserveSMSSend(ctx context.Context, index string, now time.Time) error {
+	db := model.DB.WithContext(ctx)
+	interval := time.Duration(config.Config.SMSSendInterval) * time.Second
+	day := now.Format("2006-01-02")
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.SMSSendCounter{
+		PhoneIndex: index,
+		LastSentAt: now.Add(-interval),
+		Day:        day,
+	}).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+
+	// 当天已发送过时累加次数，否则从 1 开始计数
+	result := db.Model(&model.SMSSendCounter{}).
+		Where("phone_index = ? AND last_sent_at <= ? AND day = ? AND day_count < ?", index, now.Add(-interval), day, config.Config.SMSDailyLimit).
+		Updates(map[string]any{"last_sent_at": now, "day_count": gorm.Expr("day_count + 1")})
+	if result.Error != nil {
+		return common.ErrNew(result.Error, common.SysErr)
+	}
+	if result.RowsAffected == 0 && config.Config.SMSDailyLimit > 0 {
+		result = db.Model(&model.SMSSendCounter{}).
+			Where("phone_index = ? AND last_sent_at <= ? AND day <> ?", index, now.Add(-interval), day).
+			Updates(map[string]any{"last_sent_at": now, "day": day, "day_count": 1})
+		if result.Error != nil {
+			return common.ErrNew(result.Error, common.SysErr)
+		}
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var counter model.SMSSendCounter
+	if err := db.Take(&counter, "phone_index = ?", index).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if counter.Day == day && counter.DayCount >= config.Config.SMSDailyLimit {
+		return common.ErrNew(errors.New("今日发送次数已达上限"), common.TooManyErr)
+	}
+	return common.ErrNew(errors.New("发送过于频繁，请稍后再试"), common.TooManyErr)
+}
+
+// verify 校验最近一次发送的验证码，成功后验证码失效
+func (s *SMS) verify(phone, purpose, code string) error {
+	var record model.SMSCode
+	err := model.DB.Order("id DESC").
+		Take(&record, "phone_index = ? AND purpose = ?", phoneIndex(phone), purpose).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return common.ErrNew(errors.New("验证码错误或已过期"), common.AuthErr)
+	}
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	now := time.Now()
+	if record.UsedAt != nil || !now.Before(record.ExpiresAt) || record.Attempts >= smsMaxAttempts {
+		return common.ErrNew(errors.New("验证码错误或已过期"), common.AuthErr)
+	}
+	if subtle.ConstantTimeCompare([]byte(record.Hash), []byte(model.BlindIndex("sms-code", phone+":"+code))) != 1 {
+		if err := model.DB.Model(&record).Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		return common.ErrNew(errors.New("验证码错误或已过期"), common.AuthErr)
+	}
+	// 条件更新保证并发提交时验证码只能使用一次
+	result := model.DB.Model(&model.SMSCode{}).
+		Where("id = ? AND used_at IS NULL AND attempts < ?", record.ID, smsMaxAttempts).
+		Update("used_at", now)
+	if result.Error != nil {
+		return common.ErrNew(result.Error, common.SysErr)
+	}
+	if result.RowsAffected == 0 {
+		return common.ErrNew(errors.New("验证码错误或已过期"), common.AuthErr)
+	}
+	return nil
+}
+
+// Login 使用验证码登录，手机号未注册时自动创建用户
+func (s *SMS) Login(phone, code string) (*model.User, error) {
+	phone, err := sms.NormalizePhone(phone)
+	if err != nil {
+		return nil, common.ErrNew(errors.New("手机号格式错误"), common.ParamErr)
+	}
+
+	if err := s.verify(phone, model.SMSLogin, code); err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	err = model.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Take(&user, "phone_index = ?", phoneIndex(phone)).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.ErrNew(err, common.SysErr)
+		}
+
+		username, err := availableUsername(tx, "u"+phone[7:])
+		if err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		index := phoneIndex(phone)
+		user = model.User{Username: username, Level: common.LevelUser, Phone: phone, PhoneIndex: &index}
+		if err := tx.Create(&user).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Bind 为已登录用户绑定或更换手机号
+func (s *SMS) Bind(ctx context.Context, userID int, phone, code string) error {
+	phone, err := sms.NormalizePhone(phone)
+	if err != nil {
+		return common.ErrNew(errors.New("手机号格式错误"), common.ParamErr)
+	}
+	if err := s.verify(phone, model.SMSBind, code); err != nil {
+		return err
+	}
+	return bindPhone(ctx, userID, phone)
+}
+
+// bindPhone 保存已验证的手机号，同一手机号只能绑定一个用户
+func bindPhone(ctx context.Context, userID int, phone string) error {
+	index := phoneIndex(phone)
+	return model.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.User{}).Where("phone_index = ? AND id <> ?", index, userID).Count(&count).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		if count > 0 {
+			return common.ErrNew(errors.New("手机号已被其他账号绑定"), common.OpErr)
+		}
+		if err := tx.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).
+			Select("phone", "phone_index").
+			Updates(&model.User{Phone: phone, PhoneIndex: &index}).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		return nil
+	})
+}
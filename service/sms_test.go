@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"template/common"
+	"template/config"
+	"template/model"
+	"template/pkg/sms"
+)
+
+type failingSMSProvider struct{}
+
+func (failingSMSProvider) Send(context.Context, sms.Message) error {
+	return errors.New("provider unavailable")
+}
+
+// 服务商发送失败时退回已占用的短信配额
+func TestSendCode_ReleasesQuotaOnSendFailure(t *testing.T) {
+	fake := useFakeResourceDB(t)
+	saved, savedProvider := config.Config, SMSProvider
+	t.Cleanup(func() { config.Config, SMSProvider = saved, savedProvider })
+	config.Config.RateLimitStore = "memory"
+	config.Config.SMSIPHourlyLimit = 100
+	config.Config.SMSDailyLimit = 10
+	SMSProvider = func() (sms.Provider, error) { return failingSMSProvider{}, nil }
+
+	ctx := model.ContextWithActor(context.Background(), model.Actor{UserID: 7, Level: common.LevelUser})
+	if err := (&SMS{}).SendCode(ctx, "13800138000", "login", "127.0.0.1"); err == nil {
+		t.Fatal("expected send error")
+	}
+
+	var consumed, released bool
+	for _, q := range fake.queries {
+		if strings.HasPrefix(q, "UPDATE `quota_usage`") {
+			switch {
+			case strings.Contains(q, "`used`=used +"):
+				consumed = true
+			case strings.Contains(q, "GREATEST(used -"):
+				released = consumed
+			}
+		}
+	}
+	if !consumed || !released {
+		t.Fatalf("quota should be consumed then released, queries: %v", fake.queries)
+	}
+}
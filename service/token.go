@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+
+	"template/common"
+	"template/config"
+	"template/logger"
+	"template/model"
+	"template/pkg/idgen"
+	"template/pkg/jwt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Token struct {
+}
+
+const (
+	TokenAccess  = "access"
+	TokenRefresh = "refresh"
+)
+
+// TokenClaims 令牌声明，sid 为登录会话ID，与 cookie 会话共用会话登记
+type TokenClaims struct {
+	jwt.RegisteredClaims
+	Type      string `json:"typ"`
+	Username  string `json:"name"`
+	Level     int    `json:"lvl"`
+	SessionID string `json:"sid"`
+	MFA       bool   `json:"mfa,omitempty"`
+}
+
+type TokenPair struct {
+	TokenType    string `json:"tokenType"`
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
+
+var tokenKeyring = sync.OnceValues(loadTokenKeyring)
+
+func loadTokenKeyring() (*jwt.Keyring, error) {
+	alg := config.Config.JWTAlg
+	if config.Config.JWTKeys == "" {
+		if alg != jwt.HS256 {
+			return nil, errors.New("APP_JWT_KEYS is required for " + alg)
+		}
+		// 未配置时由 APP_SECRET 派生，生产环境必须显式配置
+		if config.Config.AppProd {
+			return nil, errors.New("APP_JWT_KEYS must be configured in production")
+		}
+		derived := sha256.Sum256([]byte("jwt:" + config.Config.AppSecret))
+		return jwt.NewKeyring(alg, map[string]any{"0": derived[:]}, "0")
+	}
+	keys, err := jwt.ParseKeys(alg, config.Config.JWTKeys)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.NewKeyring(alg, keys, config.Config.JWTKeyID)
+}
+
+// startTokenGC 首次签发或注销令牌时启动，定期删除已过期的刷新令牌和注销记录
+var startTokenGC = sync.OnceFunc(func() {
+	go func() {
+		for range time.Tick(time.Hour) {
+			if err := (&Token{}).GC(context.Background(), time.Now()); err != nil {
+				logger.Errorf("token gc error: %v", err)
+			}
+		}
+	}()
+})
+
+func refreshTTL() time.Duration {
+	return time.Duration(config.Config.JWTRefreshTTL) * time.Second
+}
+
+// Issue 登录成功后签发令牌，并登记为新的登录会话，mfa 表示已完成两步验证
+func (t *Token) Issue(user *model.User, mfa bool, ip, userAgent string) (*TokenPair, error) {
+	startTokenGC()
+	sid, err := (&ActiveSession{}).Register(int(user.ID), ip, userAgent, refreshTTL())
+	if err != nil {
+		return nil, err
+	}
+	return t.issue(model.DB, user, sid, mfa)
+}
+
+func (t *Token) issue(tx *gorm.DB, user *model.User, sid string, mfa bool) (*TokenPair, error) {
+	keyring, err := tokenKeyring()
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	now := time.Now()
+	refresh := model.RefreshToken{
+		UserID:    int(user.ID),
+		Family:    sid,
+		ExpiresAt: now.Add(refreshTTL()),
+	}
+	if err := tx.Create(&refresh).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+
+	claims := TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    config.Config.JWTIssuer,
+			Subject:   user.ID.String(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Duration(config.Config.JWTAccessTTL) * time.Second).Unix(),
+			ID:        idgen.NewULID(),
+		},
+		Type:      TokenAccess,
+		Username:  user.Username,
+		Level:     user.Level,
+		SessionID: sid,
+		MFA:       mfa,
+	}
+	access, err := keyring.Sign(claims)
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+
+	claims.Type = TokenRefresh
+	claims.ID = refresh.ID
+	claims.ExpiresAt = refresh.ExpiresAt.Unix()
+	claims.Level = 0
+	refreshToken, err := keyring.Sign(claims)
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+
+	return &TokenPair{
+		TokenType:    "Bearer",
+		AccessToken:  access,
+		RefreshToken: refreshToken,
+		ExpiresIn:    config.Config.JWTAccessTTL,
+	}, nil
+}
+
+// parse 校验签名、有效期及令牌类型
+func (t *Token) parse(raw, typ string) (*TokenClaims, error) {
+	keyring, err := tokenKeyring()
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	var claims TokenClaims
+	if _, err := keyring.Parse(raw, &claims); err != nil {
+		return nil, common.ErrNew(errors.New("令牌无效"), common.AuthErr)
+	}
+	if err := claims.Validate(time.Now(), 0, config.Config.JWTIssuer, ""); err != nil {
+		return nil, common.ErrNew(errors.New("令牌已过期"), common.AuthErr)
+	}
+	if claims.Type != typ || claims.ID == "" || claims.SessionID == "" {
+		return nil, common.ErrNew(errors.New("令牌无效"), common.AuthErr)
+	}
+	return &claims, nil
+}
+
+// Verify 校验访问令牌，已注销的令牌返回错误
+func (t *Token) Verify(raw string) (*TokenClaims, error) {
+	claims, err := t.parse(raw, TokenAccess)
+	if err != nil {
+		return nil, err
+	}
+	var count int64
+	if err := model.DB.Model(&model.RevokedToken{}).Where("id = ?", claims.ID).Count(&count).Error; err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	if count > 0 {
+		return nil, common.ErrNew(errors.New("令牌已注销"), common.AuthErr)
+	}
+	return claims, nil
+}
+
+// Refresh 使用刷新令牌轮换出新的令牌对，已使用过的刷新令牌再次出现时视为泄露，注销整个令牌族
+func (t *Token) Refresh(raw string) (*TokenPair, error) {
+	claims, err := t.parse(raw, TokenRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	var pair *TokenPair
+	var reused bool
+	err = model.DB.Transaction(func(tx *gorm.DB) error {
+		var refresh model.RefreshToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&refresh, "id = ?", claims.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.ErrNew(errors.New("令牌无效"), common.AuthErr)
+		}
+		if err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		if refresh.RevokedAt != nil {
+			return common.ErrNew(errors.New("令牌已注销"), common.AuthErr)
+		}
+		if refresh.UsedAt != nil {
+			reused = true
+			return nil
+		}
+
+		var session model.ActiveSession
+		if err := tx.Take(&session, "id = ?", refresh.Family).Error; err != nil || session.RevokedAt != nil {
+			return common.ErrNew(errors.New("登录已失效"), common.AuthErr)
+		}
+		var user model.User
+		if err := tx.Take(&user, refresh.UserID).Error; err != nil {
+			return common.ErrNew(errors.New("用户不存在"), common.AuthErr)
+		}
+		now := time.Now()
+		if err := tx.Model(&refresh).Update("used_at", now).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		if err := tx.Model(&session).Updates(map[string]any{
+			"last_seen_at": now,
+			"expires_at":   now.Add(refreshTTL()),
+		}).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		pair, err = t.issue(tx, &user, refresh.Family, claims.MFA)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		if err := t.revokeFamily(claims.SessionID); err != nil {
+			return nil, err
+		}
+		return nil, common.ErrNew(errors.New("刷新令牌已被使用，请重新登录"), common.AuthErr)
+	}
+	return pair, nil
+}
+
+// Revoke 注销访问令牌及其所属的令牌族
+func (t *Token) Revoke(claims *TokenClaims) error {
+	startTokenGC()
+	revoked := model.RevokedToken{ID: claims.ID, ExpiresAt: time.Unix(claims.ExpiresAt, 0)}
+	if err := model.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return t.revokeFamily(claims.SessionID)
+}
+
+func (t *Token) revokeFamily(sid string) error {
+	if err := model.DB.Model(&model.RefreshToken{}).
+		Where("family = ? AND revoked_at IS NULL", sid).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	return (&ActiveSession{}).End(sid)
+}
+
+// GC 删除 before 之前过期的刷新令牌和注销记录，过期令牌的签名校验已无法通过
+func (t *Token) GC(ctx context.Context, before time.Time) error {
+	db := model.DB.WithContext(ctx)
+	if err := db.Unscoped().Where("expires_at < ?", before).Delete(&model.RefreshToken{}).Error; err != nil {
+		return err
+	}
+	return db.Where("expires_at < ?", before).Delete(&model.RevokedToken{}).Error
+}
+
+// JWKS 签名公钥集合，供其他服务校验令牌
+func (t *Token) JWKS() (jwt.JWKS, error) {
+	keyring, err := tokenKeyring()
+	if err != nil {
+		return jwt.JWKS{}, common.ErrNew(err, common.SysErr)
+	}
+	return keyring.JWKS(), nil
+}
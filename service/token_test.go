@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"template/config"
+	"template/pkg/jwt"
+)
+
+// 过期的刷新令牌直接删除而不是软删除，注销记录同样被清理
+func TestTokenGC(t *testing.T) {
+	fake := useFakeResourceDB(t)
+	if err := (&Token{}).GC(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	var refresh, revoked bool
+	for _, q := range fake.queries {
+		switch {
+		case strings.HasPrefix(q, "DELETE FROM `refresh_token` WHERE expires_at <"):
+			refresh = true
+		case strings.HasPrefix(q, "DELETE FROM `revoked_token` WHERE expires_at <"):
+			revoked = true
+		}
+	}
+	if !refresh || !revoked {
+		t.Fatalf("expected expired tokens to be deleted, queries: %v", fake.queries)
+	}
+}
+
+func TestLoadTokenKeyring_ProdRequiresKeys(t *testing.T) {
+	saved := config.Config
+	t.Cleanup(func() { config.Config = saved })
+
+	config.Config.JWTAlg = jwt.HS256
+	config.Config.JWTKeys = ""
+	config.Config.AppProd = true
+	if _, err := loadTokenKeyring(); err == nil {
+		t.Fatal("expected error without APP_JWT_KEYS in production")
+	}
+
+	config.Config.AppProd = false
+	if _, err := loadTokenKeyring(); err != nil {
+		t.Fatal(err)
+	}
+}
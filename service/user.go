@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"template/common"
+	"template/logger"
+	"template/model"
+	"template/pkg/password"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type User struct {
+}
+
+// 用户不存在时用于校验的哈希，使响应时间与密码错误时一致，避免枚举用户名
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := password.Hash("dummy-password")
+	return hash
+})
+
+// Register 注册用户，email 不为空时与用户一同保存，保存后发送验证邮件
+func (u *User) Register(ctx context.Context, username, pwd, email string, level int) (*model.User, error) {
+	hash, err := password.Hash(pwd)
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	user := model.User{Username: username, Password: hash, Level: level}
+	if email != "" {
+		email = normalizeEmail(email)
+		user.Email = &email
+	}
+
+	// 依赖唯一索引判断用户名和邮箱是否已被使用，避免先查询后插入的并发竞争
+	err = model.DB.WithContext(ctx).Create(&user).Error
+	if key, ok := model.DuplicateKey(err); ok {
+		if strings.Contains(key, "email") {
+			return nil, common.ErrNew(errors.New("邮箱已被使用"), common.OpErr)
+		}
+		return nil, common.ErrNew(errors.New("用户名已存在"), common.OpErr)
+	}
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+
+	// 账号已创建，邮件发送失败时用户可重新请求验证邮件
+	if user.Email != nil {
+		if err := (&Account{}).SendVerification(ctx, int(user.ID)); err != nil {
+			logger.Errorf("send verification to user %d: %v", user.ID, err)
+		}
+	}
+	return &user, nil
+}
+
+// Login 校验用户名密码，哈希参数过时时重新计算并保存
+func (u *User) Login(username, pwd string) (*model.User, error) {
+	var user model.User
+	err := model.DB.Take(&user, "username = ?", username).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		_, _, _ = password.Verify(dummyHash(), pwd)
+		return nil, common.ErrNew(errors.New("用户名或密码错误"), common.AuthErr)
+	}
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+
+	if err := u.verify(&user, pwd); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (u *User) Get(ctx context.Context, id int) (*model.User, error) {
+	var user model.User
+	err := model.DB.WithContext(ctx).Take(&user, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, common.ErrNew(errors.New("用户不存在"), common.NotFoundErr)
+	}
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &user, nil
+}
+
+// ChangePassword 修改密码并注销该用户的所有会话
+func (u *User) ChangePassword(ctx context.Context, id int, oldPwd, newPwd string) error {
+	user, err := u.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := u.verify(user, oldPwd); err != nil {
+		var ginErr *gin.Error
+		if errors.As(err, &ginErr) && ginErr.Type == common.AuthErr {
+			return common.ErrNew(errors.New("原密码错误"), common.AuthErr)
+		}
+		return err
+	}
+
+	hash, err := password.Hash(newPwd)
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if err := model.DB.Model(user).Update("password", hash).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	_, err = (&ActiveSession{}).RevokeAll(ctx, id)
+	return err
+}
+
+// SetLevel 修改用户权限等级，已登录的会话会被注销以使新权限生效
+func (u *User) SetLevel(username string, level int) error {
+	var user model.User
+	err := model.DB.Take(&user, "username = ?", username).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return common.ErrNew(errors.New("用户不存在"), common.NotFoundErr)
+	}
+	if err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if err := model.DB.Model(&user).Update("level", level).Error; err != nil {
+		return common.ErrNew(err, common.SysErr)
+	}
+	_, err = (&ActiveSession{}).RevokeAll(context.Background(), int(user.ID))
+	return err
+}
+
+// verify 校验密码，需要时重新计算哈希
+func (u *User) verify(user *model.User, pwd string) error {
+	ok, rehash, err := password.Verify(user.Password, pwd)
+	// 通过外部身份提供方创建的用户没有本地密码
+	if err != nil && !errors.Is(err, password.ErrInvalidHash) {
+		return common.ErrNew(err, common.SysErr)
+	}
+	if !ok {
+		return common.ErrNew(errors.New("用户名或密码错误"), common.AuthErr)
+	}
+	if rehash {
+		hash, err := password.Hash(pwd)
+		if err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+		if err := model.DB.Model(user).Update("password", hash).Error; err != nil {
+			return common.ErrNew(err, common.SysErr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"template/common"
+
+	"github.com/gin-gonic/gin"
+	gomysql "github.com/go-sql-driver/mysql"
+)
+
+// 唯一索引冲突按索引名区分用户名和邮箱，其他错误不应被当作重复
+func TestRegister_DuplicateKey(t *testing.T) {
+	cases := []struct {
+		name    string
+		err     error
+		message string
+		errType gin.ErrorType
+	}{
+		{"username", &gomysql.MySQLError{Number: 1062, Message: "Duplicate entry 'alice' for key 'user.idx_user_username'"}, "用户名已存在", common.OpErr},
+		{"email", &gomysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@example.com' for key 'idx_user_email'"}, "邮箱已被使用", common.OpErr},
+		{"other", &gomysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, "Lock wait timeout exceeded", common.SysErr},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := useFakeResourceDB(t)
+			fake.execErr = func(query string) error {
+				if strings.HasPrefix(query, "INSERT INTO `user`") {
+					return tc.err
+				}
+				return nil
+			}
+
+			_, err := (&User{}).Register(context.Background(), "alice", "password123", "a@example.com", common.LevelUser)
+			var ginErr *gin.Error
+			if !errors.As(err, &ginErr) || ginErr.Type != tc.errType || !strings.Contains(err.Error(), tc.message) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			for _, q := range fake.queries {
+				if strings.HasPrefix(q, "SELECT count(*)") {
+					t.Errorf("register should rely on the unique index: %s", q)
+				}
+			}
+		})
+	}
+}
@@ -24,6 +24,10 @@ var validatorHandleRouter = map[string]validateHandle{
 		timing,
 		timingTransZh,
 	},
+	"mobile": {
+		mobile,
+		mobileTransZh,
+	},
 }
 
 var Trans ut.Translator
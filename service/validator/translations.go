@@ -7,3 +7,7 @@ import (
 func timingTransZh(ut ut.Translator) error {
 	return ut.Add("timing", "{0}输入的时间不符合要求", true)
 }
+
+func mobileTransZh(ut ut.Translator) error {
+	return ut.Add("mobile", "{0}必须是有效的手机号", true)
+}
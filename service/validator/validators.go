@@ -3,6 +3,8 @@ package validator
 import (
 	"time"
 
+	"template/pkg/sms"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -15,3 +17,9 @@ func timing(fl validator.FieldLevel) bool {
 	}
 	return true
 }
+
+// mobile 中国大陆手机号，允许 +86 前缀
+func mobile(fl validator.FieldLevel) bool {
+	_, err := sms.NormalizePhone(fl.Field().String())
+	return err == nil
+}
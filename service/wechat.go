@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"template/common"
+	"template/config"
+	"template/model"
+	"template/pkg/sms"
+	"template/pkg/wechat"
+
+	"gorm.io/gorm"
+)
+
+type Wechat struct {
+}
+
+type WechatPhoneResponse struct {
+	Phone string `json:"phone"`
+}
+
+func wechatClient() (*wechat.Client, error) {
+	if config.Config.WechatAppID == "" {
+		return nil, common.ErrNew(errors.New("未配置小程序登录"), common.OpErr)
+	}
+	return &wechat.Client{
+		AppID:   config.Config.WechatAppID,
+		Secret:  config.Config.WechatSecret,
+		BaseURL: config.Config.WechatBaseURL,
+	}, nil
+}
+
+// Login 使用 wx.login 的 code 登录，openid 未绑定时按 unionid 关联已有用户，否则自动创建用户
+func (w *Wechat) Login(ctx context.Context, code string) (*model.User, error) {
+	client, err := wechatClient()
+	if err != nil {
+		return nil, err
+	}
+	session, err := client.Code2Session(ctx, code)
+	var wxErr *wechat.Error
+	if errors.As(err, &wxErr) {
+		return nil, common.ErrNew(errors.New("微信登录失败，请重试"), common.AuthErr)
+	}
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+
+	var user model.User
+	err = model.DB.Transaction(func(tx *gorm.DB) error {
+		var binding model.WechatBinding
+		err := tx.Take(&binding, "app_id = ? AND open_id = ?", client.AppID, session.OpenID).Error
+		if err == nil {
+			updates := map[string]any{"session_key": session.SessionKey}
+			if session.UnionID != "" {
+				updates["union_id"] = session.UnionID
+			}
+			if err := tx.Model(&binding).Updates(updates).Error; err != nil {
+				return err
+			}
+			return tx.Take(&user, binding.UserID).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var linked model.WechatBinding
+		if session.UnionID != "" {
+			err = tx.Take(&linked, "union_id = ?", session.UnionID).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		if linked.UserID != 0 {
+			if err := tx.Take(&user, linked.UserID).Error; err != nil {
+				return err
+			}
+		} else {
+			username, err := availableUsername(tx, "wx")
+			if err != nil {
+				return err
+			}
+			// 小程序用户不设置本地密码，无法使用密码登录
+			user = model.User{Username: username, Level: common.LevelUser}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		}
+		binding = model.WechatBinding{
+			UserID:     int(user.ID),
+			AppID:      client.AppID,
+			OpenID:     session.OpenID,
+			UnionID:    session.UnionID,
+			SessionKey: session.SessionKey,
+		}
+		return tx.Create(&binding).Error
+	})
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	return &user, nil
+}
+
+// decrypt 使用当前用户最近一次登录的 session_key 解密
+func (w *Wechat) decrypt(userID int, encryptedData, iv string, v any) (*model.WechatBinding, error) {
+	client, err := wechatClient()
+	if err != nil {
+		return nil, err
+	}
+	var binding model.WechatBinding
+	err = model.DB.Take(&binding, "user_id = ? AND app_id = ?", userID, client.AppID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, common.ErrNew(errors.New("未使用小程序登录"), common.OpErr)
+	}
+	if err != nil {
+		return nil, common.ErrNew(err, common.SysErr)
+	}
+	if err := client.Decrypt(binding.SessionKey, encryptedData, iv, v); err != nil {
+		return nil, common.ErrNew(errors.New("数据解密失败，请重新登录小程序"), common.AuthErr)
+	}
+	return &binding, nil
+}
+
+// BindPhone 解密 getPhoneNumber 返回的数据并绑定手机号
+func (w *Wechat) BindPhone(ctx context.Context, userID int, encryptedData, iv string) (*WechatPhoneResponse, error) {
+	var info wechat.PhoneInfo
+	if _, err := w.decrypt(userID, encryptedData, iv, &info); err != nil {
+		return nil, err
+	}
+	if info.CountryCode != "86" {
+		return nil, common.ErrNew(errors.New("仅支持中国大陆手机号"), common.ParamErr)
+	}
+	phone, err := sms.NormalizePhone(info.PurePhoneNumber)
+	if err != nil {
+		return nil, common.ErrNew(errors.New("手机号格式错误"), common.ParamErr)
+	}
+	if err := bindPhone(ctx, userID, phone); err != nil {
+		return nil, err
+	}
+	return &WechatPhoneResponse{Phone: sms.Mask(phone)}, nil
+}
+
+// UserInfo 解密 getUserInfo 返回的数据，包含 unionid 时保存
+func (w *Wechat) UserInfo(userID int, encryptedData, iv string) (*wechat.UserInfo, error) {
+	var info wechat.UserInfo
+	binding, err := w.decrypt(userID, encryptedData, iv, &info)
+	if err != nil {
+		return nil, err
+	}
+	if info.OpenID != "" && info.OpenID != binding.OpenID {
+		return nil, common.ErrNew(errors.New("数据与当前账号不一致"), common.AuthErr)
+	}
+	if info.UnionID != "" && binding.UnionID == "" {
+		if err := model.DB.Model(binding).Update("union_id", info.UnionID).Error; err != nil {
+			return nil, common.ErrNew(err, common.SysErr)
+		}
+	}
+	return &info, nil
+}